@@ -0,0 +1,120 @@
+// Command action runs go-prompts as a GitHub Actions step; see action.yml
+// at the root of the repository. It is configured through the INPUT_*
+// environment variables set by the action, each of which sets the
+// go-prompts flag of the same name. Inputs left empty fall back, as on the
+// command line, to the config file and the flag defaults, and order.txt is
+// read when it exists.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
+	"gosuda.org/goprompts/internal/cli"
+	"gosuda.org/goprompts/prompts"
+)
+
+// flagInputs maps action inputs to the go-prompts flags they set.
+var flagInputs = []struct{ input, flag string }{
+	{"CONFIG", "config"},
+	{"DIRS", "dirs"},
+	{"OUT", "out"},
+	{"EXT", "ext"},
+	{"TAGS", "tags"},
+	{"REQUIRE_TAGS", "require-tags"},
+	{"STRIP_FRONT_MATTER", "strip-front-matter"},
+	{"TOC", "toc"},
+	{"HEADERS", "headers"},
+}
+
+func main() {
+	log.SetFlags(0)
+
+	flags := flag.NewFlagSet("go-prompts-action", flag.ContinueOnError)
+	m := cli.AddMergeFlags(flags)
+	for _, fi := range flagInputs {
+		if v := input(fi.input, ""); v != "" {
+			if err := flags.Set(fi.flag, v); err != nil {
+				log.Fatalf("input %s: %v", strings.ToLower(fi.input), err)
+			}
+		}
+	}
+	// The cache would be left behind in the workspace.
+	flags.Set("no-cache", "true")
+	if err := m.LoadConfig(false); err != nil {
+		log.Fatal(err)
+	}
+	dirs, opts, err := m.Options()
+	if err != nil {
+		log.Fatal(err)
+	}
+	out := *m.Out
+	failOnChange := input("FAIL_ON_CHANGE", "false") == "true"
+
+	committed, err := os.ReadFile(out)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatal(err)
+	}
+
+	if err := prompts.MergePrompts(dirs, out, opts); err != nil {
+		log.Fatal(err)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sum, err := prompts.ComputeChecksum(f)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := setOutput("prompt-sha256", sum); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Merged %s (sha256 %s)\n", out, sum)
+
+	if failOnChange {
+		generated, err := os.ReadFile(out)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !bytes.Equal(generated, committed) {
+			fmt.Print(prompts.UnifiedDiff(out, out+" (generated)", committed, generated))
+			fmt.Printf("::error file=%s::%s is out of date; regenerate it with go-prompts and commit the result\n", out, out)
+			os.Exit(1)
+		}
+	}
+}
+
+// input returns the action input name, or def if it is not set.
+func input(name, def string) string {
+	if v := strings.TrimSpace(os.Getenv("INPUT_" + name)); v != "" {
+		return v
+	}
+	return def
+}
+
+// setOutput sets a step output by appending it to the file named by
+// GITHUB_OUTPUT. Outside of Actions it does nothing.
+func setOutput(name, value string) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o666)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "%s=%s\n", name, value); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
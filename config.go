@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gosuda.org/goprompts/internal/cli"
+)
+
+// ruleSetting configures a validate rule.
+type ruleSetting struct {
+	Disabled bool
+	Limit    int // the rule's threshold; zero keeps its default
+}
+
+// readRules reads the rules key of the config file at path, which maps
+// rule names to false, to disable the rule, or to a number, to set its
+// threshold. A missing file is only an error if explicit is set.
+func readRules(path string, explicit bool) (map[string]ruleSetting, error) {
+	values, err := cli.ReadConfig(path, explicit)
+	if err != nil || values[cli.RulesKey] == nil {
+		return nil, err
+	}
+	rules, ok := values[cli.RulesKey].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s: expected a mapping of rule names", path, cli.RulesKey)
+	}
+	settings := make(map[string]ruleSetting, len(rules))
+	for name, v := range rules {
+		switch v := v.(type) {
+		case bool:
+			settings[name] = ruleSetting{Disabled: !v}
+		case int:
+			settings[name] = ruleSetting{Limit: v}
+		default:
+			return nil, fmt.Errorf("%s: %s: %s: expected true, false or a number", path, cli.RulesKey, name)
+		}
+	}
+	return settings, nil
+}
+
+// writeExampleConfig writes a config file listing every flag in flags with
+// its usage and default value, all commented out.
+func writeExampleConfig(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintf(w, "# %s: go-prompts configuration.\n", cli.DefaultConfigFile)
+	fmt.Fprintf(w, "#\n")
+	fmt.Fprintf(w, "# Keys are the command-line flags in snake_case. Flags given on the\n")
+	fmt.Fprintf(w, "# command line override the values set here. Uncomment to change a\n")
+	fmt.Fprintf(w, "# default.\n")
+	flags.VisitAll(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		fmt.Fprintf(w, "\n# %s\n", f.Usage)
+		fmt.Fprintf(w, "# %s: %s\n", strings.ReplaceAll(f.Name, "-", "_"), exampleValue(f))
+	})
+	fmt.Fprintf(w, "\n# Rules of go-prompts validate: false disables a rule, a number sets\n")
+	fmt.Fprintf(w, "# its threshold.\n")
+	fmt.Fprintf(w, "# %s:\n", cli.RulesKey)
+	for _, r := range validateRules {
+		fmt.Fprintf(w, "#   %s: %s\n", r.name, exampleRule(r))
+	}
+}
+
+// exampleValue formats the default of f as YAML.
+func exampleValue(f *flag.Flag) string {
+	switch v := f.Value.(type) {
+	case *cli.ListFlag:
+		return "[]"
+	case *cli.VarsFlag, *cli.FuncsFlag:
+		return "{}"
+	case flag.Getter:
+		switch d := v.Get().(type) {
+		case bool, int, int64:
+			return fmt.Sprint(d)
+		case time.Duration:
+			return strconv.Quote(d.String())
+		}
+	}
+	return strconv.Quote(f.DefValue)
+}
+
+// runConfig implements "go-prompts config". Its only command, init, writes
+// an example config file for flags to stdout.
+func runConfig(args []string, flags *flag.FlagSet) {
+	if len(args) != 1 || args[0] != "init" {
+		fmt.Fprintf(os.Stderr, "Usage: go-prompts config init > %s\n", cli.DefaultConfigFile)
+		os.Exit(2)
+	}
+	writeExampleConfig(os.Stdout, flags)
+}
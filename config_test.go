@@ -0,0 +1,25 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigPrecedenceCLI(t *testing.T) {
+	dir := writeTree(t, t.TempDir(), map[string]string{
+		"general/a.md":     "# A\n",
+		".go-prompts.yaml": "dirs: general\nout: config.md\n",
+	})
+	if _, stderr, code := runCLI(t, dir); code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, stderr)
+	}
+	if _, stderr, code := runCLI(t, dir, "-out=flag.md"); code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, stderr)
+	}
+	// The first run writes where the config says, the second where the
+	// flag says, and neither to the default prompt.md.
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	if len(matches) != 2 || filepath.Base(matches[0]) != "config.md" || filepath.Base(matches[1]) != "flag.md" {
+		t.Errorf("outputs = %q, want config.md and flag.md", matches)
+	}
+}
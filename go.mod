@@ -1,3 +1,5 @@
 module gosuda.org/goprompts
 
 go 1.25rc2
+
+require gopkg.in/yaml.v3 v3.0.1
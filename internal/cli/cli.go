@@ -0,0 +1,86 @@
+// Package cli holds the flags and the config file shared by the go-prompts
+// commands and the GitHub Action, so that all of them merge, list and count
+// the same files for the same flags and .go-prompts.yaml.
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A UsageError reports an invalid flag value. Commands print it with their
+// usage and exit with status 2, as for a flag that does not parse.
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string { return e.Err.Error() }
+
+func (e *UsageError) Unwrap() error { return e.Err }
+
+// SplitList splits a comma-separated flag value, dropping empty entries.
+func SplitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// ListFlag is a flag.Value collecting comma-separated values across
+// repeated uses of the same flag.
+type ListFlag []string
+
+func (l *ListFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *ListFlag) Set(s string) error {
+	*l = append(*l, SplitList(s)...)
+	return nil
+}
+
+// VarsFlag is a flag.Value collecting KEY=VALUE pairs.
+type VarsFlag map[string]string
+
+func (v *VarsFlag) String() string {
+	pairs := make([]string, 0, len(*v))
+	for k, val := range *v {
+		pairs = append(pairs, k+"="+val)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (v *VarsFlag) Set(s string) error {
+	if *v == nil {
+		*v = VarsFlag{}
+	}
+	for _, pair := range SplitList(s) {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("%q is not KEY=VALUE", pair)
+		}
+		(*v)[key] = value
+	}
+	return nil
+}
+
+// FuncsFlag is a flag.Value collecting NAME=COMMAND pairs, one per use of
+// the flag, so that commands can contain commas.
+type FuncsFlag map[string]string
+
+func (f *FuncsFlag) String() string { return (*VarsFlag)(f).String() }
+
+func (f *FuncsFlag) Set(s string) error {
+	name, command, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("%q is not NAME=COMMAND", s)
+	}
+	if *f == nil {
+		*f = FuncsFlag{}
+	}
+	(*f)[name] = command
+	return nil
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is read for flag values when it exists and -config is
+// not given.
+const DefaultConfigFile = ".go-prompts.yaml"
+
+// RulesKey is the config file key that configures the rules of
+// "go-prompts validate" rather than a flag.
+const RulesKey = "rules"
+
+// ApplyConfig sets the flags that were not given on the command line from
+// the YAML config file at path. Keys are flag names in snake_case,
+// e.g. strip_front_matter for -strip-front-matter. A missing file is only
+// an error if explicit is set.
+//
+// With a nil only, every key must name a flag of flags. Otherwise only the
+// flags in only are set and other keys are ignored, so that a command
+// taking some of the flags can share the config file of the others.
+func ApplyConfig(flags *flag.FlagSet, path string, explicit bool, only map[string]bool) error {
+	values, err := ReadConfig(path, explicit)
+	if err != nil {
+		return err
+	}
+
+	given := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if key == RulesKey {
+			continue
+		}
+		name := strings.ReplaceAll(key, "_", "-")
+		if only != nil && !only[name] {
+			continue
+		}
+		if flags.Lookup(name) == nil || name == "config" {
+			return fmt.Errorf("%s: unknown key %q", path, key)
+		}
+		if given[name] {
+			continue
+		}
+		for _, value := range configValues(flags.Lookup(name), values[key]) {
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ReadConfig parses the YAML config file at path. A missing file is only
+// an error if explicit is set.
+func ReadConfig(path string, explicit bool) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// configValues returns the values to set f to for the YAML value v: one
+// per item of a list or mapping for a FuncsFlag, which takes one entry per
+// use, and otherwise v as a single configValue.
+func configValues(f *flag.Flag, v any) []string {
+	if _, ok := f.Value.(*FuncsFlag); !ok {
+		return []string{configValue(v)}
+	}
+	switch v := v.(type) {
+	case []any:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = configValue(item)
+		}
+		return values
+	case map[string]any:
+		values := make([]string, 0, len(v))
+		for key, value := range v {
+			values = append(values, key+"="+configValue(value))
+		}
+		sort.Strings(values)
+		return values
+	}
+	return []string{configValue(v)}
+}
+
+// configValue formats a YAML value as a flag value: lists are
+// comma-separated and mappings become KEY=VALUE pairs.
+func configValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = configValue(item)
+		}
+		return strings.Join(items, ",")
+	case map[string]any:
+		pairs := make([]string, 0, len(v))
+		for key, value := range v {
+			pairs = append(pairs, key+"="+configValue(value))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	}
+	return fmt.Sprint(v)
+}
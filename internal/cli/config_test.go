@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes config to a file in a temporary directory and returns
+// its path.
+func writeConfig(t *testing.T, config string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), DefaultConfigFile)
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// configFlags returns a flag set with a flag of each kind ApplyConfig
+// handles.
+func configFlags() (*flag.FlagSet, *string, *bool, *int, *ListFlag) {
+	fs := flag.NewFlagSet("go-prompts", flag.ContinueOnError)
+	out := fs.String("out", "prompt.md", "")
+	toc := fs.Bool("toc", false, "")
+	maxChars := fs.Int("max-chars", 0, "")
+	var exclude ListFlag
+	fs.Var(&exclude, "exclude", "")
+	fs.String("config", "", "")
+	return fs, out, toc, maxChars, &exclude
+}
+
+func TestApplyConfigPrecedence(t *testing.T) {
+	path := writeConfig(t, "out: from-config.md\ntoc: true\nmax_chars: 100\nexclude: [\"*.draft.md\", drafts/*]\n")
+
+	tests := []struct {
+		args     []string
+		out      string
+		toc      bool
+		maxChars int
+		exclude  string
+	}{
+		{nil, "from-config.md", true, 100, "*.draft.md,drafts/*"},
+		{[]string{"-out=flag.md", "-toc=false"}, "flag.md", false, 100, "*.draft.md,drafts/*"},
+		{[]string{"-max-chars=0", "-exclude=old/*"}, "from-config.md", true, 0, "old/*"},
+	}
+	for _, tt := range tests {
+		fs, out, toc, maxChars, exclude := configFlags()
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		if err := ApplyConfig(fs, path, true, nil); err != nil {
+			t.Fatal(err)
+		}
+		if *out != tt.out || *toc != tt.toc || *maxChars != tt.maxChars || exclude.String() != tt.exclude {
+			t.Errorf("%q: out=%q toc=%v max-chars=%d exclude=%q, want %q %v %d %q",
+				tt.args, *out, *toc, *maxChars, exclude, tt.out, tt.toc, tt.maxChars, tt.exclude)
+		}
+	}
+}
+
+func TestApplyConfigMissing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".go-prompts.yaml")
+	fs, out, _, _, _ := configFlags()
+	if err := ApplyConfig(fs, path, false, nil); err != nil {
+		t.Errorf("missing default config: %v", err)
+	}
+	if *out != "prompt.md" {
+		t.Errorf("out = %q, want the default", *out)
+	}
+	if err := ApplyConfig(fs, path, true, nil); err == nil {
+		t.Error("missing explicit config: no error")
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{"no_such_flag: 1\n", `unknown key "no_such_flag"`},
+		{"config: other.yaml\n", `unknown key "config"`},
+		{"max_chars: lots\n", "max_chars"},
+		{"out: [unclosed\n", "yaml"},
+	}
+	for _, tt := range tests {
+		path := writeConfig(t, tt.config)
+		fs, _, _, _, _ := configFlags()
+		err := ApplyConfig(fs, path, true, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: err = %v, want it to mention %q", tt.config, err, tt.err)
+		}
+	}
+}
+
+func TestApplyConfigOnly(t *testing.T) {
+	path := writeConfig(t, "out: from-config.md\ntoc: true\nwatch: true\n")
+	fs, out, toc, _, _ := configFlags()
+	if err := ApplyConfig(fs, path, true, map[string]bool{"out": true}); err != nil {
+		t.Fatal(err)
+	}
+	if *out != "from-config.md" || *toc {
+		t.Errorf("out=%q toc=%v, want only out set", *out, *toc)
+	}
+}
@@ -0,0 +1,268 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gosuda.org/goprompts/prompts"
+)
+
+// DefaultExt is the default of -ext.
+var DefaultExt = strings.Join(prompts.DefaultMergeOptions().Extensions, ",")
+
+// MergeFlags are the flags that select the files to merge and how they are
+// rendered: those of the go-prompts command that end up in
+// prompts.MergeOptions.
+type MergeFlags struct {
+	// Out is the value of -out, the path of the merged output.
+	Out *string
+
+	fs    *flag.FlagSet
+	names map[string]bool // the flags added to fs
+
+	config          *string
+	dirs            *string
+	urls            *string
+	fetchTimeout    *time.Duration
+	strictRemote    *bool
+	gitRef          *string
+	strictDirs      *bool
+	ext             *string
+	sort            *string
+	orderFile       *string
+	strictOrder     *bool
+	strip           *bool
+	normalize       *bool
+	priority        *bool
+	toc             *bool
+	headers         *bool
+	headerTmpl      *string
+	expandEnv       *bool
+	template        *bool
+	templatePlugin  *string
+	functions       FuncsFlag
+	vars            VarsFlag
+	strictVars      *bool
+	dedup           *bool
+	debug           *bool
+	include         ListFlag
+	exclude         ListFlag
+	sections        ListFlag
+	requireSection  *bool
+	tags            *string
+	requireTags     *bool
+	format          *string
+	maxChars        *int
+	truncate        *string
+	noCache         *bool
+	logFile         *string
+	parallelism     *int
+	streamThreshold *int64
+	maxFileSize     *int64
+	strictSize      *bool
+}
+
+// AddMergeFlags defines the merge flags in fs, except those named in
+// except, which keep their defaults and leave the name free for a flag of
+// the command's own.
+func AddMergeFlags(fs *flag.FlagSet, except ...string) *MergeFlags {
+	m := &MergeFlags{fs: fs, names: map[string]bool{}}
+	all := flag.NewFlagSet(fs.Name(), flag.ContinueOnError)
+	m.config = all.String("config", "", "YAML file of flag values, with snake_case keys (default: "+DefaultConfigFile+" if it exists)")
+	m.dirs = all.String("dirs", "general,libs", "comma-separated list of directories, or prompt file URLs, to scan; \"-\" reads file paths from stdin, one per line or NUL-separated")
+	m.urls = all.String("urls", "", "comma-separated list of prompt file URLs to merge along with -dirs")
+	m.fetchTimeout = all.Duration("fetch-timeout", prompts.DefaultFetchTimeout, "timeout for each URL download")
+	m.strictRemote = all.Bool("strict-remote", false, "fail instead of skipping URLs that cannot be fetched")
+	m.gitRef = all.String("git-ref", "", "read the files from this Git branch, tag or commit instead of the working tree")
+	m.strictDirs = all.Bool("strict-dirs", false, "fail instead of warning when one -dirs entry is inside another")
+	m.Out = all.String("out", "prompt.md", "path of the merged output file")
+	m.ext = all.String("ext", DefaultExt, "comma-separated list of file extensions to merge")
+	m.sort = all.String("sort", string(prompts.SortLexicographic), "file order: lexicographic, depth-first, breadth-first or mtime")
+	m.orderFile = all.String("order-file", "", "file listing paths to merge first, one per line (default: "+prompts.DefaultOrderFile+" if it exists)")
+	m.strictOrder = all.Bool("strict-order", false, "fail when the order file lists a path that does not exist")
+	m.strip = all.Bool("strip-front-matter", false, "remove front matter blocks from the merged output")
+	m.normalize = all.Bool("normalize", true, "convert CRLF to LF, strip trailing whitespace and end each file with one newline")
+	m.priority = all.Bool("sort-priority", false, "order files by front matter priority, highest first")
+	m.toc = all.Bool("toc", false, "prepend a table of contents linking to each merged file")
+	m.headers = all.Bool("headers", false, "write a source comment before each merged file")
+	m.headerTmpl = all.String("header-tmpl", prompts.DefaultHeaderTemplate, "text/template for -headers with .Path, .Index, .Title and .ModTime; empty disables headers")
+	m.expandEnv = all.Bool("expand-env", false, "substitute ${VAR} placeholders with environment variables")
+	m.template = all.Bool("template", false, "execute each file as a Go text/template before merging")
+	m.templatePlugin = all.String("template-plugin", "", "with -template, a Go plugin whose Funcs variable adds template functions; needs a build with -tags goprompts_plugin")
+	all.Var(&m.functions, "functions", "with -template, a NAME=COMMAND template function that runs COMMAND, split into arguments as by a shell, with its own arguments appended (repeatable)")
+	all.Var(&m.vars, "vars", "KEY=VALUE placeholder definitions, comma-separated; take precedence over the environment (repeatable)")
+	m.strictVars = all.Bool("strict-vars", false, "fail when a placeholder names an undefined variable")
+	m.dedup = all.Bool("dedup", false, "drop paragraphs already merged from an earlier file")
+	m.debug = all.Bool("debug", false, "log debug messages, such as removed duplicates")
+	all.Var(&m.include, "include", "comma-separated glob patterns; only matching files are merged (repeatable)")
+	all.Var(&m.exclude, "exclude", "comma-separated glob patterns; matching files are skipped (repeatable)")
+	all.Var(&m.sections, "section", `merge only the sections under this heading, e.g. "## Examples", from every file (repeatable)`)
+	m.requireSection = all.Bool("require-section", false, "with -section, fail on files that have none of the sections")
+	m.tags = all.String("tags", "", "comma-separated list of front matter tags; only files with one of them are merged")
+	m.requireTags = all.Bool("require-tags", false, "with -tags, also skip files that have no tags")
+	m.format = all.String("format", string(prompts.FormatMarkdown), "output format: md, txt or json")
+	m.maxChars = all.Int("max-chars", 0, "limit the merged Markdown to this many characters (0 disables the limit)")
+	m.truncate = all.String("truncate", string(prompts.TruncateEnd), "what to do beyond -max-chars: end, middle or error")
+	m.noCache = all.Bool("no-cache", false, "always read every source file instead of reusing "+prompts.DefaultCacheFile)
+	m.logFile = all.String("log-file", "", "path of the JSON merge log (default: the output path with a .log.json extension)")
+	m.parallelism = all.Int("parallelism", runtime.NumCPU(), "number of files to read concurrently")
+	m.streamThreshold = all.Int64("stream-threshold", prompts.DefaultStreamThreshold, "stream merges larger than this many bytes file by file into a temporary file renamed into place (0 disables)")
+	m.maxFileSize = all.Int64("max-file-size", prompts.DefaultMaxFileSize, "skip files larger than this many bytes (0 disables the limit)")
+	m.strictSize = all.Bool("strict-size", false, "fail instead of skipping files over -max-file-size")
+
+	all.VisitAll(func(f *flag.Flag) {
+		if !slices.Contains(except, f.Name) {
+			fs.Var(f.Value, f.Name, f.Usage)
+			m.names[f.Name] = true
+		}
+	})
+	return m
+}
+
+// LoadConfig sets the merge flags not given on the command line from -config,
+// or DefaultConfigFile if it exists. With strict, every key of the file must
+// name a flag of the flag set, as for the go-prompts command; otherwise keys
+// that name no merge flag are ignored.
+func (m *MergeFlags) LoadConfig(strict bool) error {
+	path := *m.config
+	if path == "" {
+		path = DefaultConfigFile
+	}
+	var only map[string]bool
+	if !strict {
+		only = m.names
+	}
+	return ApplyConfig(m.fs, path, *m.config != "", only)
+}
+
+// LogPath returns the path of the merge log: -log-file, or the default for
+// -out.
+func (m *MergeFlags) LogPath() string {
+	if *m.logFile != "" {
+		return *m.logFile
+	}
+	return prompts.LogPath(*m.Out)
+}
+
+// Options returns the directories and options the flags select. Invalid
+// flag values are reported as a *UsageError. With "-" in -dirs, the files
+// to merge are read from stdin.
+func (m *MergeFlags) Options() ([]string, prompts.MergeOptions, error) {
+	format, err := prompts.ParseFormat(*m.format)
+	if err != nil {
+		return nil, prompts.MergeOptions{}, &UsageError{err}
+	}
+	truncate, err := prompts.ParseTruncateStrategy(*m.truncate)
+	if err != nil {
+		return nil, prompts.MergeOptions{}, &UsageError{err}
+	}
+	sortMode, err := prompts.ParseSortMode(*m.sort)
+	if err != nil {
+		return nil, prompts.MergeOptions{}, &UsageError{err}
+	}
+
+	dirs := append(SplitList(*m.dirs), SplitList(*m.urls)...)
+	opts := prompts.DefaultMergeOptions()
+	opts.Extensions = SplitList(*m.ext)
+	opts.Include = m.include
+	opts.Exclude = m.exclude
+	opts.Tags = SplitList(*m.tags)
+	opts.Sections = m.sections
+	opts.RequireSection = *m.requireSection
+	opts.RequireTags = *m.requireTags
+	opts.SortMode = sortMode
+	opts.StrictDirs = *m.strictDirs
+	if *m.gitRef != "" {
+		opts.Source = prompts.GitSource{Ref: *m.gitRef}
+	}
+	opts.StrictOrder = *m.strictOrder
+	opts.StripFrontMatter = *m.strip
+	opts.Normalize = *m.normalize
+	opts.SortByPriority = *m.priority
+	opts.TOC = *m.toc
+	opts.Format = format
+	if opts.GeneratedAt, err = generatedAt(); err != nil {
+		return nil, opts, err
+	}
+	opts.ExpandEnv = *m.expandEnv
+	opts.Vars = m.vars
+	opts.StrictVars = *m.strictVars
+	opts.Template = *m.template
+	if *m.template {
+		if opts.TemplateFuncs, err = templateFuncs(*m.templatePlugin, m.functions); err != nil {
+			return nil, opts, err
+		}
+	}
+	opts.Dedup = *m.dedup
+	opts.FetchTimeout = *m.fetchTimeout
+	opts.StrictRemote = *m.strictRemote
+	opts.MaxChars = *m.maxChars
+	opts.Parallelism = *m.parallelism
+	opts.MaxFileSize = *m.maxFileSize
+	opts.StreamThreshold = *m.streamThreshold
+	opts.StrictSize = *m.strictSize
+	opts.Truncate = truncate
+	if !*m.noCache {
+		opts.CacheFile = prompts.DefaultCacheFile
+	}
+	// With the output inside a merged directory, neither it nor the files
+	// written beside it are prompts.
+	out := *m.Out
+	opts.Skip = []string{out, out + ".gz", prompts.ChecksumPath(out), prompts.ChecksumPath(out + ".gz"), m.LogPath(), prompts.DefaultLockFile}
+	opts.Logger = slog.Default()
+	if *m.debug {
+		opts.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	if *m.headers {
+		opts.HeaderTemplate = *m.headerTmpl
+	}
+	if slices.Contains(dirs, prompts.StdinDir) {
+		if opts.Files, err = prompts.ReadFileList(os.Stdin); err != nil {
+			return nil, opts, err
+		}
+		// Listed files keep their input order unless -sort asks otherwise.
+		opts.Sort = false
+		m.fs.Visit(func(f *flag.Flag) {
+			if f.Name == "sort" {
+				opts.Sort = true
+			}
+		})
+	}
+	if opts.Order, err = readOrder(*m.orderFile); err != nil {
+		return nil, opts, err
+	}
+	return dirs, opts, nil
+}
+
+// readOrder reads the order file at path, or prompts.DefaultOrderFile if
+// path is empty and that file exists.
+func readOrder(path string) ([]string, error) {
+	if path == "" {
+		if _, err := os.Stat(prompts.DefaultOrderFile); err != nil {
+			return nil, nil
+		}
+		path = prompts.DefaultOrderFile
+	}
+	return prompts.ReadOrderFile(path)
+}
+
+// generatedAt returns the time to record in JSON output: SOURCE_DATE_EPOCH,
+// in seconds since 1970, when set for a reproducible build, or else now.
+func generatedAt() (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Now(), nil
+	}
+	sec, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("SOURCE_DATE_EPOCH: %w", err)
+	}
+	return time.Unix(sec, 0), nil
+}
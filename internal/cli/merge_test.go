@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestMergeFlagsConfig(t *testing.T) {
+	path := writeConfig(t, "dirs: [a, b]\nstrip_front_matter: true\nformat: txt\nwatch: true\n")
+
+	// A command with its own -format takes the other merge flags from the
+	// config file and ignores the keys of flags it does not have.
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	m := AddMergeFlags(fs, "format")
+	format := fs.String("format", "table", "")
+	if err := fs.Parse([]string{"-config", path, "-dirs=c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LoadConfig(false); err != nil {
+		t.Fatal(err)
+	}
+	dirs, opts, err := m.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dirs, []string{"c"}) || !opts.StripFrontMatter || opts.Format != "md" || *format != "table" {
+		t.Errorf("dirs=%q strip=%v format=%q own format=%q", dirs, opts.StripFrontMatter, opts.Format, *format)
+	}
+
+	// The go-prompts command rejects keys it has no flag for.
+	fs = flag.NewFlagSet("go-prompts", flag.ContinueOnError)
+	m = AddMergeFlags(fs)
+	if err := fs.Parse([]string{"-config", path}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LoadConfig(true); err == nil {
+		t.Error("strict config with an unknown key: no error")
+	}
+}
+
+func TestMergeFlagsOptions(t *testing.T) {
+	fs := flag.NewFlagSet("go-prompts", flag.ContinueOnError)
+	m := AddMergeFlags(fs)
+	if err := fs.Parse([]string{"-out=dir/out.md", "-log-file=out.log", "-no-cache", "-headers", "-include=*.md,x/*"}); err != nil {
+		t.Fatal(err)
+	}
+	_, opts, err := m.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantSkip := []string{"dir/out.md", "dir/out.md.gz", "dir/out.md.sha256", "dir/out.md.gz.sha256", "out.log", ".prompt-lock"}
+	if !reflect.DeepEqual(opts.Skip, wantSkip) {
+		t.Errorf("skip = %q, want %q", opts.Skip, wantSkip)
+	}
+	if opts.CacheFile != "" || opts.HeaderTemplate == "" || !reflect.DeepEqual(opts.Include, []string{"*.md", "x/*"}) {
+		t.Errorf("cache=%q header=%q include=%q", opts.CacheFile, opts.HeaderTemplate, opts.Include)
+	}
+
+	fs = flag.NewFlagSet("go-prompts", flag.ContinueOnError)
+	m = AddMergeFlags(fs)
+	if err := fs.Parse([]string{"-sort=random"}); err != nil {
+		t.Fatal(err)
+	}
+	var usage *UsageError
+	if _, _, err := m.Options(); !errors.As(err, &usage) {
+		t.Errorf("-sort=random: err = %v, want a UsageError", err)
+	}
+}
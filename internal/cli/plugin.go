@@ -0,0 +1,27 @@
+//go:build goprompts_plugin
+
+package cli
+
+import (
+	"fmt"
+	"plugin"
+	"text/template"
+)
+
+// pluginFuncs returns the template functions exported by the Go plugin at
+// path as a variable Funcs of type template.FuncMap.
+func pluginFuncs(path string) (template.FuncMap, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup("Funcs")
+	if err != nil {
+		return nil, err
+	}
+	funcs, ok := sym.(*template.FuncMap)
+	if !ok {
+		return nil, fmt.Errorf("%s: Funcs is a %T, not a template.FuncMap", path, sym)
+	}
+	return *funcs, nil
+}
@@ -0,0 +1,15 @@
+//go:build !goprompts_plugin
+
+package cli
+
+import (
+	"fmt"
+	"text/template"
+)
+
+// pluginFuncs fails: Go plugins are only loaded by builds with the
+// goprompts_plugin tag, which need cgo and a plugin built with the same
+// toolchain and dependencies.
+func pluginFuncs(path string) (template.FuncMap, error) {
+	return nil, fmt.Errorf("%s: -template-plugin needs go-prompts built with -tags goprompts_plugin", path)
+}
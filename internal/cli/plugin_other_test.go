@@ -0,0 +1,15 @@
+//go:build !goprompts_plugin
+
+package cli
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplatePluginUnsupported(t *testing.T) {
+	_, err := templateFuncs("funcs.so", nil)
+	if err == nil || !strings.Contains(err.Error(), "goprompts_plugin") {
+		t.Errorf("err = %v, want it to name the goprompts_plugin build tag", err)
+	}
+}
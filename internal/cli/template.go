@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs collects the functions for -template: those exported by the
+// plugin at pluginPath, if any (see pluginFuncs), and one per entry of
+// commands, which runs the command with the function's arguments appended
+// and returns its output.
+func templateFuncs(pluginPath string, commands map[string]string) (template.FuncMap, error) {
+	funcs := template.FuncMap{}
+	if pluginPath != "" {
+		pf, err := pluginFuncs(pluginPath)
+		if err != nil {
+			return nil, err
+		}
+		for name, fn := range pf {
+			funcs[name] = fn
+		}
+	}
+	for name, command := range commands {
+		argv, err := splitArgs(command)
+		if err != nil {
+			return nil, fmt.Errorf("function %s: %w", name, err)
+		}
+		if len(argv) == 0 {
+			return nil, fmt.Errorf("function %s has no command", name)
+		}
+		funcs[name] = commandFunc(argv)
+	}
+	return funcs, nil
+}
+
+// splitArgs splits command into arguments as a POSIX shell would, without
+// expansions: at unquoted blanks, with single quotes preserving everything
+// up to the next one and double quotes or a backslash protecting blanks
+// and quotes. Within double quotes a backslash only escapes ", \ and $.
+func splitArgs(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+			continue
+		case c == '\\':
+			i++
+			if i == len(command) {
+				return nil, fmt.Errorf("command %q ends with a backslash", command)
+			}
+			arg.WriteByte(command[i])
+		case c == '\'':
+			end := strings.IndexByte(command[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("command %q has an unterminated quote", command)
+			}
+			arg.WriteString(command[i+1 : i+1+end])
+			i += 1 + end
+		case c == '"':
+			i++
+			for ; i < len(command) && command[i] != '"'; i++ {
+				if command[i] == '\\' && i+1 < len(command) && strings.IndexByte(`"\$`, command[i+1]) >= 0 {
+					i++
+				}
+				arg.WriteByte(command[i])
+			}
+			if i == len(command) {
+				return nil, fmt.Errorf("command %q has an unterminated quote", command)
+			}
+		default:
+			arg.WriteByte(c)
+		}
+		inArg = true
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// commandFunc returns a template function running argv plus its arguments
+// and returning the command's output without the trailing newline.
+func commandFunc(argv []string) func(args ...string) (string, error) {
+	return func(args ...string) (string, error) {
+		cmd := exec.Command(argv[0], append(argv[1:len(argv):len(argv)], args...)...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w: %s", argv[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSuffix(string(out), "\n"), nil
+	}
+}
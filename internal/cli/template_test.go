@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"flag"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{"date +%F", []string{"date", "+%F"}},
+		{"  echo   a\tb\n", []string{"echo", "a", "b"}},
+		{`printf '%s, %s' a`, []string{"printf", "%s, %s", "a"}},
+		{`echo "a \"b\" \n $HOME"`, []string{"echo", `a "b" \n $HOME`}},
+		{`echo a\ b \'c`, []string{"echo", "a b", "'c"}},
+		{`echo '' ""`, []string{"echo", "", ""}},
+		{`echo x'y'"z"`, []string{"echo", "xyz"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := splitArgs(tt.command)
+		if err != nil {
+			t.Errorf("splitArgs(%q): %v", tt.command, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.command, got, tt.want)
+		}
+	}
+
+	for _, command := range []string{`echo 'a`, `echo "a`, `echo a\`} {
+		if _, err := splitArgs(command); err == nil {
+			t.Errorf("splitArgs(%q): no error", command)
+		}
+	}
+}
+
+func TestTemplateFuncsCommand(t *testing.T) {
+	if _, err := exec.LookPath("printf"); err != nil {
+		t.Skip("no printf command")
+	}
+	var functions FuncsFlag
+	for _, arg := range []string{`join=printf '%s, %s'`, "upper=tr a-z A-Z"} {
+		if err := functions.Set(arg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	funcs, err := templateFuncs("", functions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	join := funcs["join"].(func(...string) (string, error))
+	if got, err := join("a b", "c"); err != nil || got != "a b, c" {
+		t.Errorf(`join("a b", "c") = %q, %v; want "a b, c"`, got, err)
+	}
+
+	if _, err := templateFuncs("", map[string]string{"f": `echo "a`}); err == nil || !strings.Contains(err.Error(), "function f") {
+		t.Errorf("unterminated quote: err = %v", err)
+	}
+	if _, err := templateFuncs("", map[string]string{"f": " "}); err == nil {
+		t.Error("empty command: no error")
+	}
+}
+
+func TestFuncsFlagConfig(t *testing.T) {
+	path := writeConfig(t, "functions:\n  join: printf '%s,%s'\n  day: date +%d\n")
+	fs := flag.NewFlagSet("go-prompts", flag.ContinueOnError)
+	var functions FuncsFlag
+	fs.Var(&functions, "functions", "")
+	if err := ApplyConfig(fs, path, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := FuncsFlag{"join": "printf '%s,%s'", "day": "date +%d"}
+	if !reflect.DeepEqual(functions, want) {
+		t.Errorf("functions = %q, want %q", functions, want)
+	}
+
+	if err := functions.Set("no command"); err == nil {
+		t.Error("Set without =: no error")
+	}
+}
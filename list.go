@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"gosuda.org/goprompts/internal/cli"
+	"gosuda.org/goprompts/prompts"
+)
+
+// listEntry is a file as printed by "go-prompts list".
+type listEntry struct {
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	ModTime  time.Time `json:"mtime"`
+	Tags     []string  `json:"tags"`
+	Title    string    `json:"title"`
+	Priority int       `json:"priority"`
+}
+
+// runList implements "go-prompts list", which prints the files a merge
+// with the same flags and config file would include, in merge order.
+func runList(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: go-prompts list [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Prints the files a merge with the same flags would include, in merge\n")
+		fmt.Fprintf(fs.Output(), "order. Only front matter is read, to apply -tags and disabled files.\n\n")
+		fs.PrintDefaults()
+	}
+	mergeFlags := cli.AddMergeFlags(fs)
+	formatFlag := fs.String("list-format", "json", "output format: json, tsv or plain")
+	fs.Parse(args)
+
+	switch *formatFlag {
+	case "json", "tsv", "plain":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown list format %q\n", *formatFlag)
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	dirs, opts := mergeOptions(fs, mergeFlags, false)
+	files, err := prompts.Walk(dirs, opts.WalkOptions())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if files, err = prompts.Select(files, opts); err != nil {
+		log.Fatal(err)
+	}
+
+	if *formatFlag == "plain" {
+		for _, f := range files {
+			fmt.Println(f.Path)
+		}
+		return
+	}
+
+	entries := make([]listEntry, 0, len(files))
+	for _, f := range files {
+		tags := f.FrontMatter.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		entries = append(entries, listEntry{
+			Path:     f.Path,
+			Size:     f.Info.Size(),
+			ModTime:  f.Info.ModTime(),
+			Tags:     tags,
+			Title:    f.FrontMatter.Title,
+			Priority: f.FrontMatter.Priority,
+		})
+	}
+
+	if *formatFlag == "json" {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Println("path\tsize\tmtime\ttags\ttitle\tpriority")
+	for _, e := range entries {
+		fmt.Printf("%s\t%d\t%s\t%s\t%s\t%d\n", tsvField(e.Path), e.Size, e.ModTime.Format(time.RFC3339), tsvField(strings.Join(e.Tags, ",")), tsvField(e.Title), e.Priority)
+	}
+}
+
+// tsvField replaces the tabs and newlines in s, which TSV cannot hold, with
+// spaces.
+func tsvField(s string) string {
+	return strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(s)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mergeTree is a prompt tree that a merge with its config file reads only
+// partly: a.md and c.md are merged, the rest is left out.
+var mergeTree = map[string]string{
+	".go-prompts.yaml":  "dirs: p\ntags: go\nmax_file_size: 100\n",
+	"p/a.md":            "---\ntags: [go]\n---\n# A\n",
+	"p/b.md":            "---\ndisabled: true\n---\n# B\n",
+	"p/c.md":            "# C\n",
+	"p/python.md":       "---\ntags: [python]\n---\n# Python\n",
+	"p/big.md":          strings.Repeat("big ", 50),
+	"p/prompt.md":       "# Old output\n",
+	"p/prompt.log.json": "{}\n",
+}
+
+// mergeTreeFiles are the files of mergeTree that a merge includes.
+const mergeTreeFiles = "p/a.md\np/c.md\n"
+
+func TestListMatchesMerge(t *testing.T) {
+	dir := writeTree(t, t.TempDir(), mergeTree)
+	stdout, stderr, code := runCLI(t, dir, "list", "-out=p/prompt.md", "-list-format=plain")
+	if code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, stderr)
+	}
+	if want := filepath.FromSlash(mergeTreeFiles); stdout != want {
+		t.Errorf("list = %q, want %q", stdout, want)
+	}
+
+	if _, stderr, code := runCLI(t, dir, "-out=p/prompt.md", "-headers"); code != 0 {
+		t.Fatalf("merge: exit code %d:\n%s", code, stderr)
+	}
+	merged, err := os.ReadFile(filepath.Join(dir, "p", "prompt.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range strings.Fields(mergeTreeFiles) {
+		if !strings.Contains(string(merged), "<!-- source: "+filepath.FromSlash(name)+" -->") {
+			t.Errorf("merge did not include %s:\n%s", name, merged)
+		}
+	}
+	if n := strings.Count(string(merged), "<!-- source:"); n != 2 {
+		t.Errorf("merge included %d files, want 2:\n%s", n, merged)
+	}
+}
@@ -1,55 +1,383 @@
 package main
 
 import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"sort"
+	"strconv"
+	"strings"
+
+	"gosuda.org/goprompts/internal/cli"
+	"gosuda.org/goprompts/prompts"
 )
 
 func main() {
-	dirs := []string{"general", "libs"}
-	var files []string
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "split":
+			runSplit(os.Args[2:])
+			return
+		case "validate":
+			runValidate(os.Args[2:])
+			return
+		case "verify":
+			runVerify(os.Args[2:])
+			return
+		case "list":
+			runList(os.Args[2:])
+			return
+		case "preview":
+			runPreview(os.Args[2:])
+			return
+		case "stats":
+			runStats(os.Args[2:])
+			return
+		case "reorder":
+			runReorder(os.Args[2:])
+			return
+		}
+	}
 
-	for _, dir := range dirs {
-		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: go-prompts [flags]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       go-prompts split [flags] [merged-file]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       go-prompts validate [flags] [file-or-dir ...]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       go-prompts verify [flags] [merged-file]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       go-prompts list [flags]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       go-prompts preview [flags]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       go-prompts stats [flags]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       go-prompts reorder [flags]\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "       go-prompts config init\n\n")
+		flag.PrintDefaults()
+	}
+	mergeFlags := cli.AddMergeFlags(flag.CommandLine)
+	noLogFlag := flag.Bool("no-log", false, "do not write the JSON merge log")
+	diffFlag := flag.Bool("diff", false, "print a unified diff of the changes to the output on stderr")
+	diffOnlyFlag := flag.Bool("diff-only", false, "print the diff on stdout without writing; exit 1 if the output is stale")
+	checksumFlag := flag.Bool("checksum-file", false, "write the SHA-256 of the output, or with -compress-only of the .gz, to its path with .sha256 appended")
+	compressFlag := flag.Bool("compress", false, "also write the output gzip-compressed, with .gz appended to its path")
+	compressOnlyFlag := flag.Bool("compress-only", false, "write only the gzip-compressed output")
+	compressLevelFlag := flag.Int("compress-level", prompts.DefaultCompressLevel, "gzip compression level, from 1 (fastest) to 9 (smallest)")
+	multiOutputFlag := flag.Bool("multi-output", false, "route content after <!-- split: name.md --> lines to name.md next to the output")
+	hashGuardFlag := flag.Bool("hash-guard", false, "refuse to overwrite the output if it was edited since the last run, tracked in "+prompts.DefaultLockFile)
+	forceFlag := flag.Bool("force", false, "with -hash-guard, overwrite the output even if it was edited")
+	dryRunFlag := flag.Bool("dry-run", false, "list the files that would be merged without writing any output")
+	watchFlag := flag.Bool("watch", false, "keep running and re-merge whenever a source file changes")
+	tokenizerFlag := flag.String("tokenizer", prompts.TokenizerEstimate, "tokenizer used for the token summary: words, chars or estimate, an approximation of BPE tokenizers such as cl100k_base")
+	serveFlag := flag.String("serve", "", "serve the HTTP API on this address, e.g. :8080, instead of writing the output")
+	tokenFlag := flag.String("token", "", "with -serve, require this bearer token on every request")
+	modeFlag := flag.String("mode", string(prompts.ModeFull), "full regenerates the output; append adds new files to it and append-update also rewrites changed ones")
+	tokenLimitFlag := flag.Int("token-limit", 0, "exit with an error if the merged output exceeds this many tokens (0 disables the check)")
+
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		runConfig(os.Args[2:], flag.CommandLine)
+		return
+	}
+	flag.Parse()
+
+	dirs, opts := mergeOptions(flag.CommandLine, mergeFlags, true)
+	outFlag := mergeFlags.Out
+	logPath := mergeFlags.LogPath()
+	// The cache records offsets into the whole merge, which -multi-output
+	// does not write as such.
+	if *multiOutputFlag {
+		opts.CacheFile = ""
+	}
+
+	if _, err := prompts.CountTokens("", *tokenizerFlag); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *compressLevelFlag < gzip.BestSpeed || *compressLevelFlag > gzip.BestCompression {
+		fmt.Fprintf(os.Stderr, "-compress-level must be between %d and %d\n", gzip.BestSpeed, gzip.BestCompression)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	mode, err := prompts.ParseMergeMode(*modeFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *serveFlag != "" {
+		log.Fatal(serve(*serveFlag, *tokenFlag, dirs, opts))
+	}
+
+	// build merges dirs, or with -mode=append or append-update extends
+	// the existing output. A missing output is generated in full. When
+	// nothing needs the document in memory, a full merge is written to
+	// the output as it is built, streamed over -stream-threshold.
+	stream := !*diffFlag && !*diffOnlyFlag && !*multiOutputFlag && !*compressOnlyFlag
+	build := func() (*prompts.Result, error) {
+		if mode == prompts.ModeFull && stream {
+			return prompts.WriteMerge(dirs, *outFlag, opts)
+		}
+		if mode == prompts.ModeFull {
+			return prompts.Merge(dirs, opts)
+		}
+		existing, err := os.ReadFile(*outFlag)
+		if errors.Is(err, fs.ErrNotExist) {
+			return prompts.Append(nil, dirs, opts, prompts.ModeFull)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return prompts.Append(existing, dirs, opts, mode)
+	}
+
+	// merge writes the output and prints the summary line. Exceeding the
+	// token limit is reported as an error after the output is written.
+	// With -diff-only nothing is written and stale records whether the
+	// output would have changed.
+	stale := false
+	merge := func() error {
+		if *hashGuardFlag && !*forceFlag && !*compressOnlyFlag && !*diffOnlyFlag {
+			if err := prompts.CheckLock(prompts.DefaultLockFile, *outFlag); err != nil {
+				return fmt.Errorf("%w; rerun with -force to overwrite it", err)
+			}
+		}
+		res, err := build()
+		if err != nil {
+			return err
+		}
+		var outputs map[string][]byte
+		if *multiOutputFlag {
+			if outputs, err = splitOutputs(res, *outFlag); err != nil {
+				return err
+			}
+		}
+		if *diffFlag || *diffOnlyFlag {
+			old, err := os.ReadFile(*outFlag)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			diff := prompts.UnifiedDiff(*outFlag, *outFlag+" (generated)", old, res.Content)
+			stale = diff != ""
+			if *diffOnlyFlag {
+				fmt.Print(diff)
+				return nil
+			}
+			fmt.Fprint(os.Stderr, diff)
+		}
+		if !*compressOnlyFlag {
+			if err := res.WriteFile(*outFlag); err != nil {
+				return err
+			}
+			if *hashGuardFlag {
+				if err := res.WriteLock(prompts.DefaultLockFile, *outFlag, flagValues(flag.CommandLine)); err != nil {
+					return err
+				}
+			}
+		}
+		names := make([]string, 0, len(outputs))
+		for name := range outputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if err := os.WriteFile(name, outputs[name], 0o666); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s\n", name)
+		}
+		if *compressFlag || *compressOnlyFlag {
+			stats, err := res.WriteCompressed(*outFlag+".gz", *compressLevelFlag)
 			if err != nil {
 				return err
 			}
-			if !info.IsDir() && filepath.Ext(path) == ".md" {
-				files = append(files, path)
+			fmt.Printf("Compressed to %s: %s -> %s bytes (%.1f%%)\n", stats.Path, groupDigits(stats.UncompressedBytes), groupDigits(stats.CompressedBytes), 100*stats.Ratio)
+		}
+		// With -compress-only the output left in place may be stale, so
+		// the checksum is of the compressed file that was written.
+		if *checksumFlag && *compressOnlyFlag {
+			if err := prompts.WriteFileChecksum(*outFlag+".gz", prompts.ChecksumPath(*outFlag+".gz")); err != nil {
+				return err
+			}
+		} else if *checksumFlag {
+			if err := res.WriteChecksum(prompts.ChecksumPath(*outFlag), *outFlag); err != nil {
+				return err
 			}
-			return nil
-		})
+		}
+		if !*noLogFlag {
+			if err := res.WriteLog(logPath, flagValues(flag.CommandLine)); err != nil {
+				return err
+			}
+		}
+		opts.Logger.Debug("normalized files", "count", res.Normalized)
+		tokens, err := res.CountTokens(*tokenizerFlag)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Merged %d files | %s tokens (%s)\n", len(res.Files), groupDigits(tokens), prompts.TokenizerName(*tokenizerFlag))
+		if *tokenLimitFlag > 0 && tokens > *tokenLimitFlag {
+			return fmt.Errorf("%s exceeds the token limit: %d > %d", *outFlag, tokens, *tokenLimitFlag)
+		}
+		return nil
 	}
 
-	sort.Strings(files)
+	if *dryRunFlag {
+		// No file is read: the size is projected from the file sizes, and
+		// files that front matter would leave out are still listed.
+		files, err := prompts.Walk(dirs, opts.WalkOptions())
+		if err != nil {
+			log.Fatal(err)
+		}
+		size := 0
+		for i, f := range files {
+			fmt.Printf("%3d. %s\n", i+1, f.Path)
+			if f.Info != nil {
+				size += int(f.Info.Size()) + len(opts.Separator)
+			}
+		}
+		fmt.Printf("Would merge %d files (about %s bytes) to %s\n", len(files), groupDigits(size), *outFlag)
+		if !*watchFlag {
+			return
+		}
+	}
 
-	outFile, err := os.Create("prompt.md")
-	if err != nil {
-		log.Fatal(err)
+	if !*watchFlag {
+		if err := merge(); err != nil {
+			log.Fatal(err)
+		}
+		if *diffOnlyFlag {
+			if stale {
+				os.Exit(1)
+			}
+			return
+		}
+		written := *outFlag
+		if *compressOnlyFlag {
+			written += ".gz"
+		}
+		fmt.Printf("Successfully merged markdown files to %s\n", written)
+		return
 	}
-	defer outFile.Close()
 
-	for _, file := range files {
-		content, err := ioutil.ReadFile(file)
-		if err != nil {
-			log.Printf("Failed to read file %s: %v", file, err)
-			continue
+	rebuild := func() {
+		if *dryRunFlag {
+			return
 		}
-		_, err = outFile.Write(content)
-		if err != nil {
-			log.Printf("Failed to write to prompt.md: %v", err)
+		if err := merge(); err != nil {
+			log.Printf("Rebuild failed: %v", err)
+			return
+		}
+		if !*diffOnlyFlag {
+			log.Printf("Rebuilt %s", *outFlag)
+		}
+	}
+	rebuild()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// The files listed on stdin are watched in place of "-".
+	var watched []string
+	for _, dir := range dirs {
+		if dir == prompts.StdinDir {
+			watched = append(watched, opts.Files...)
+		} else {
+			watched = append(watched, dir)
+		}
+	}
+	w := &prompts.Watcher{Dirs: watched, Extensions: opts.Extensions}
+	log.Printf("Watching %s for changes", strings.Join(dirs, ", "))
+	w.Run(ctx, func(events []prompts.Event) {
+		changed := false
+		for _, ev := range events {
+			// Our own writes must not trigger another rebuild when the
+			// output lives inside a watched directory.
+			if filepath.Clean(ev.Path) == filepath.Clean(*outFlag) {
+				continue
+			}
+			log.Printf("%s %s", ev.Op, ev.Path)
+			changed = true
+		}
+		if changed {
+			rebuild()
+		}
+	})
+}
+
+// splitOutputs applies the split directives in res to -multi-output: it
+// keeps the content for out in res and returns that for the other outputs by
+// path. Directive names are relative to the directory of out.
+func splitOutputs(res *prompts.Result, out string) (map[string][]byte, error) {
+	parts := prompts.SplitDirectives(res.Content)
+	res.Content = parts[""]
+	outputs := map[string][]byte{}
+	for name, content := range parts {
+		if name == "" {
 			continue
 		}
-		// Add a newline between files for separation
-		_, err = outFile.WriteString("\n")
-		if err != nil {
-			log.Printf("Failed to write to prompt.md: %v", err)
+		if !filepath.IsLocal(name) {
+			return nil, fmt.Errorf("split directive names %q, which is not a local path", name)
+		}
+		path := filepath.Join(filepath.Dir(out), name)
+		if path == filepath.Clean(out) {
+			res.Content = append(res.Content, content...)
+			continue
 		}
+		outputs[path] = content
 	}
+	return outputs, nil
+}
 
-	fmt.Println("Successfully merged markdown files to prompt.md")
+// mergeOptions loads the config file into the merge flags m of fs and
+// returns the directories and options they select. It exits as for
+// flag.ExitOnError on invalid flag values. See MergeFlags.LoadConfig for
+// strict.
+func mergeOptions(fs *flag.FlagSet, m *cli.MergeFlags, strict bool) ([]string, prompts.MergeOptions) {
+	if err := m.LoadConfig(strict); err != nil {
+		log.Fatal(err)
+	}
+	dirs, opts, err := m.Options()
+	var usage *cli.UsageError
+	if errors.As(err, &usage) {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return dirs, opts
+}
+
+// secretFlags are the flags whose values are redacted by flagValues.
+var secretFlags = map[string]bool{"token": true}
+
+// flagValues returns the effective value of every flag in fs, as recorded
+// in the merge log and lock file. Secrets that are set are replaced by
+// "REDACTED".
+func flagValues(fs *flag.FlagSet) map[string]string {
+	values := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = f.Value.String()
+		if secretFlags[f.Name] && values[f.Name] != "" {
+			values[f.Name] = "REDACTED"
+		}
+	})
+	return values
+}
+
+// groupDigits formats n with a space between each group of three digits,
+// e.g. 8342 as "8 342".
+func groupDigits(n int) string {
+	s := strconv.Itoa(n)
+	for i := len(s) - 3; i > 0 && s[i-1] != '-'; i -= 3 {
+		s = s[:i] + " " + s[i:]
+	}
+	return s
 }
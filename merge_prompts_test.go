@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gosuda.org/goprompts/prompts"
+)
+
+// TestMain runs main instead of the tests when the test binary is started
+// by runCLI.
+func TestMain(m *testing.M) {
+	if os.Getenv("GO_PROMPTS_RUN_MAIN") == "1" {
+		os.Args = append([]string{"go-prompts"}, os.Args[1:]...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCLI runs go-prompts with args in dir and returns its output and exit
+// code.
+func runCLI(t *testing.T, dir string, args ...string) (stdout, stderr string, code int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GO_PROMPTS_RUN_MAIN=1")
+	var outBuf, errBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if err != nil && !errors.As(err, &exit) {
+		t.Fatal(err)
+	}
+	return outBuf.String(), errBuf.String(), cmd.ProcessState.ExitCode()
+}
+
+func TestUnknownFlag(t *testing.T) {
+	for _, args := range [][]string{
+		{"-no-such-flag"},
+		{"list", "-no-such-flag"},
+		{"validate", "-no-such-flag"},
+	} {
+		stdout, stderr, code := runCLI(t, t.TempDir(), args...)
+		if code == 0 {
+			t.Errorf("%q: exit code 0, want non-zero", args)
+		}
+		if !strings.Contains(stderr, "flag provided but not defined: -no-such-flag") || !strings.Contains(stderr, "Usage") {
+			t.Errorf("%q: stderr does not show the error and usage:\n%s", args, stderr)
+		}
+		if stdout != "" {
+			t.Errorf("%q: stdout = %q, want nothing", args, stdout)
+		}
+	}
+}
+
+func TestDefaultFlags(t *testing.T) {
+	dir := writeTree(t, t.TempDir(), map[string]string{
+		"general/a.md": "# A\n",
+		"libs/b.md":    "# B\n",
+	})
+	stdout, stderr, code := runCLI(t, dir)
+	if code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, stderr)
+	}
+	if !strings.Contains(stdout, "Successfully merged markdown files to prompt.md") {
+		t.Errorf("stdout = %q", stdout)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "prompt.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# A\n\n# B\n\n"; string(got) != want {
+		t.Errorf("prompt.md = %q, want %q", got, want)
+	}
+}
+
+func TestFlagValuesRedactsSecrets(t *testing.T) {
+	fs := flag.NewFlagSet("go-prompts", flag.ContinueOnError)
+	fs.String("token", "", "")
+	fs.String("out", "prompt.md", "")
+	if err := fs.Parse([]string{"-token", "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+	values := flagValues(fs)
+	if got := values["token"]; got != "REDACTED" {
+		t.Errorf("token = %q, want REDACTED", got)
+	}
+	if got := values["out"]; got != "prompt.md" {
+		t.Errorf("out = %q, want prompt.md", got)
+	}
+
+	fs.Set("token", "")
+	if got := flagValues(fs)["token"]; got != "" {
+		t.Errorf("unset token = %q, want it empty", got)
+	}
+}
+
+func TestTags(t *testing.T) {
+	files := map[string]string{
+		"general/go.md":       "---\ntags: [go]\n---\nGo.\n",
+		"general/web.md":      "---\ntags:\n  - web\n  - go\n---\nWeb.\n",
+		"general/python.md":   "---\ntags: python\n---\nPython.\n",
+		"general/untagged.md": "Untagged.\n",
+	}
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{nil, "Go.\n\nPython.\n\nUntagged.\n\nWeb.\n\n"},
+		{[]string{"-tags=python"}, "Python.\n\nUntagged.\n\n"},
+		{[]string{"-tags=web"}, "Untagged.\n\nWeb.\n\n"},
+		{[]string{"-tags=python,web"}, "Python.\n\nUntagged.\n\nWeb.\n\n"},
+		{[]string{"-tags=go"}, "Go.\n\nUntagged.\n\nWeb.\n\n"},
+		{[]string{"-tags=go", "-require-tags"}, "Go.\n\nWeb.\n\n"},
+		{[]string{"-tags=python, web", "-require-tags"}, "Python.\n\nWeb.\n\n"},
+		{[]string{"-tags=rust", "-require-tags"}, ""},
+	}
+	for _, tt := range tests {
+		dir := writeTree(t, t.TempDir(), files)
+		args := append([]string{"-dirs=general", "-strip-front-matter"}, tt.args...)
+		_, stderr, code := runCLI(t, dir, args...)
+		if code != 0 {
+			t.Fatalf("%q: exit code %d:\n%s", tt.args, code, stderr)
+		}
+		got, err := os.ReadFile(filepath.Join(dir, "prompt.md"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("%q: prompt.md = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestCompressOnly(t *testing.T) {
+	dir := writeTree(t, t.TempDir(), map[string]string{
+		"general/a.md": "# A\n",
+		"prompt.md":    "stale\n",
+	})
+	for range 2 {
+		stdout, stderr, code := runCLI(t, dir, "-dirs=general", "-compress-only", "-checksum-file")
+		if code != 0 {
+			t.Fatalf("exit code %d:\n%s", code, stderr)
+		}
+		if !strings.Contains(stdout, "Successfully merged markdown files to prompt.md.gz") {
+			t.Errorf("stdout = %q", stdout)
+		}
+	}
+
+	if got, _ := os.ReadFile(filepath.Join(dir, "prompt.md")); string(got) != "stale\n" {
+		t.Errorf("prompt.md = %q, want it left alone", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "prompt.md.sha256")); err == nil {
+		t.Error("wrote a checksum of the stale prompt.md")
+	}
+	gz := filepath.Join(dir, "prompt.md.gz")
+	if err := prompts.VerifyChecksum(gz, prompts.ChecksumPath(gz)); err != nil {
+		t.Error(err)
+	}
+	cache, err := os.ReadFile(filepath.Join(dir, prompts.DefaultCacheFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(cache), `"output": "prompt.md.gz"`) {
+		t.Errorf("cache does not refer to prompt.md.gz:\n%s", cache)
+	}
+}
+
+func TestHashGuard(t *testing.T) {
+	dir := writeTree(t, t.TempDir(), map[string]string{"general/a.md": "# A\n"})
+	out := filepath.Join(dir, "prompt.md")
+	run := func(args ...string) (string, int) {
+		t.Helper()
+		_, stderr, code := runCLI(t, dir, append([]string{"-dirs=general", "-hash-guard"}, args...)...)
+		return stderr, code
+	}
+
+	if stderr, code := run(); code != 0 {
+		t.Fatalf("first run: exit code %d:\n%s", code, stderr)
+	}
+	if stderr, code := run(); code != 0 {
+		t.Fatalf("rerun: exit code %d:\n%s", code, stderr)
+	}
+
+	if err := os.WriteFile(out, []byte("# Edited by hand\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stderr, code := run()
+	if code == 0 || !strings.Contains(stderr, "-force") {
+		t.Errorf("edited output: exit code %d, stderr:\n%s", code, stderr)
+	}
+	if got, _ := os.ReadFile(out); string(got) != "# Edited by hand\n" {
+		t.Errorf("the guard overwrote the edit with %q", got)
+	}
+
+	// -force overwrites the edit and updates the lock for later runs.
+	if stderr, code := run("-force"); code != 0 {
+		t.Fatalf("-force: exit code %d:\n%s", code, stderr)
+	}
+	if got, _ := os.ReadFile(out); string(got) != "# A\n\n" {
+		t.Errorf("prompt.md = %q after -force", got)
+	}
+	if stderr, code := run(); code != 0 {
+		t.Errorf("after -force: exit code %d:\n%s", code, stderr)
+	}
+}
+
+func TestMultiOutput(t *testing.T) {
+	files := map[string]string{
+		"general/a.md": "# Shared\n<!-- split: private.md -->\nsecret\n<!-- split: prompt.md -->\nback\n",
+	}
+	dir := writeTree(t, t.TempDir(), files)
+	if err := os.Mkdir(filepath.Join(dir, "out"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, code := runCLI(t, dir, "-dirs=general", "-out=out/prompt.md", "-multi-output")
+	if code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, stderr)
+	}
+	if !strings.Contains(stdout, "Wrote "+filepath.Join("out", "private.md")) {
+		t.Errorf("stdout = %q", stdout)
+	}
+	for name, want := range map[string]string{
+		"out/prompt.md":  "# Shared\nback\n\n",
+		"out/private.md": "secret\n",
+	} {
+		if got, err := os.ReadFile(filepath.Join(dir, name)); err != nil || string(got) != want {
+			t.Errorf("%s = %q (%v), want %q", name, got, err, want)
+		}
+	}
+
+	// Without -multi-output the directives are left in place.
+	if _, stderr, code := runCLI(t, dir, "-dirs=general", "-out=plain.md"); code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, stderr)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "plain.md")); string(got) != files["general/a.md"]+"\n" {
+		t.Errorf("plain.md = %q", got)
+	}
+
+	for _, name := range []string{"../escape.md", "/tmp/abs.md"} {
+		dir := writeTree(t, t.TempDir(), map[string]string{"general/a.md": "<!-- split: " + name + " -->\nx\n"})
+		_, stderr, code := runCLI(t, dir, "-dirs=general", "-multi-output")
+		if code == 0 || !strings.Contains(stderr, "not a local path") {
+			t.Errorf("split to %s: exit code %d, stderr:\n%s", name, code, stderr)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "prompt.md")); err == nil {
+			t.Errorf("split to %s: wrote prompt.md", name)
+		}
+	}
+}
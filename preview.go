@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gosuda.org/goprompts/internal/cli"
+	"gosuda.org/goprompts/prompts"
+)
+
+// previewStyle holds the ANSI sequences used by the preview renderer. The
+// zero value renders plain text.
+type previewStyle struct {
+	heading, code, quote, link, comment, bold, reset string
+}
+
+var previewStyles = map[string]previewStyle{
+	"dark": {
+		heading: "\x1b[1;38;5;117m",
+		code:    "\x1b[38;5;222m",
+		quote:   "\x1b[38;5;245m",
+		link:    "\x1b[4;38;5;111m",
+		comment: "\x1b[38;5;240m",
+		bold:    "\x1b[1m",
+		reset:   "\x1b[0m",
+	},
+	"light": {
+		heading: "\x1b[1;38;5;25m",
+		code:    "\x1b[38;5;130m",
+		quote:   "\x1b[38;5;242m",
+		link:    "\x1b[4;38;5;26m",
+		comment: "\x1b[38;5;248m",
+		bold:    "\x1b[1m",
+		reset:   "\x1b[0m",
+	},
+	"notty": {},
+}
+
+// runPreview implements "go-prompts preview", which merges -dirs in memory
+// and renders the result to the terminal.
+func runPreview(args []string) {
+	fs := flag.NewFlagSet("preview", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: go-prompts preview [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Renders the prompt a merge with the same flags would write, with ANSI\n")
+		fmt.Fprintf(fs.Output(), "formatting, without writing it.\n\n")
+		fs.PrintDefaults()
+	}
+	mergeFlags := cli.AddMergeFlags(fs)
+	widthFlag := fs.Int("width", 0, "wrap text at this many columns (default: the terminal width, or 80)")
+	styleFlag := fs.String("style", "dark", "color style: dark, light or notty; notty is used when stdout is not a terminal")
+	tokenizerFlag := fs.String("tokenizer", prompts.TokenizerEstimate, "tokenizer used for the summary: words, chars or estimate, an approximation of BPE tokenizers such as cl100k_base")
+	fs.Parse(args)
+
+	style, ok := previewStyles[*styleFlag]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown style %q\n", *styleFlag)
+		fs.Usage()
+		os.Exit(2)
+	}
+	if _, err := prompts.CountTokens("", *tokenizerFlag); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		fs.Usage()
+		os.Exit(2)
+	}
+	if !isTerminal(os.Stdout) {
+		style = previewStyles["notty"]
+	}
+	width := *widthFlag
+	if width <= 0 {
+		width, _ = terminalSize(os.Stdout)
+	}
+	if width <= 0 {
+		width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	}
+	if width <= 0 {
+		width = 80
+	}
+
+	dirs, opts := mergeOptions(fs, mergeFlags, false)
+	res, err := prompts.Merge(dirs, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tokens, _ := prompts.CountTokens(string(res.Content), *tokenizerFlag)
+
+	w := bufio.NewWriter(os.Stdout)
+	fmt.Fprintf(w, "%s%d files | %s bytes | %s tokens (%s)%s\n", style.bold, len(res.Files), groupDigits(len(res.Content)), groupDigits(tokens), prompts.TokenizerName(*tokenizerFlag), style.reset)
+	fmt.Fprintln(w, strings.Repeat("─", width))
+	renderMarkdown(w, string(res.Content), width, style)
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+var (
+	previewHeading = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	previewRule    = regexp.MustCompile(`^\s*(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	previewList    = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+`)
+	previewComment = regexp.MustCompile(`^\s*<!--.*-->\s*$`)
+	previewCode    = regexp.MustCompile("`[^`]+`")
+	previewBold    = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	previewLink    = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	ansiSequence   = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// isTerminal reports whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// renderMarkdown writes text to w with style, wrapping prose at width.
+// Fenced code is indented and never wrapped.
+func renderMarkdown(w io.Writer, text string, width int, style previewStyle) {
+	inFence := false
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			fmt.Fprintf(w, "%s%s%s\n", style.comment, line, style.reset)
+			continue
+		}
+		switch {
+		case inFence:
+			fmt.Fprintf(w, "%s  %s%s\n", style.code, line, style.reset)
+		case previewComment.MatchString(line):
+			fmt.Fprintf(w, "%s%s%s\n", style.comment, line, style.reset)
+		case previewRule.MatchString(line):
+			fmt.Fprintln(w, strings.Repeat("─", width))
+		case previewHeading.MatchString(line):
+			m := previewHeading.FindStringSubmatch(line)
+			fmt.Fprintf(w, "%s%s %s%s\n", style.heading, m[1], strings.ReplaceAll(m[2], "**", ""), style.reset)
+		case strings.HasPrefix(trimmed, ">"):
+			body := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			for _, l := range wrap(renderInline(body, style), width-2) {
+				fmt.Fprintf(w, "%s│ %s%s\n", style.quote, l, style.reset)
+			}
+		default:
+			indent := ""
+			if m := previewList.FindString(line); m != "" {
+				indent = strings.Repeat(" ", utf8.RuneCountInString(m))
+			} else {
+				indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			}
+			lines := wrap(renderInline(line, style), width-len(indent))
+			for i, l := range lines {
+				if i > 0 {
+					l = indent + strings.TrimLeft(l, " ")
+				}
+				fmt.Fprintln(w, l)
+			}
+		}
+	}
+}
+
+// renderInline styles the code spans, bold text and links of line.
+func renderInline(line string, style previewStyle) string {
+	if style == (previewStyle{}) {
+		return line
+	}
+	line = previewCode.ReplaceAllStringFunc(line, func(s string) string {
+		return style.code + strings.Trim(s, "`") + style.reset
+	})
+	line = previewBold.ReplaceAllStringFunc(line, func(s string) string {
+		return style.bold + s[2:len(s)-2] + style.reset
+	})
+	return previewLink.ReplaceAllString(line, style.link+"$1"+style.reset+" ($2)")
+}
+
+// wrap breaks line at spaces so that no line is wider than width columns,
+// not counting ANSI sequences. Words wider than width stay whole.
+func wrap(line string, width int) []string {
+	if width <= 0 || visibleWidth(line) <= width {
+		return []string{line}
+	}
+	var lines []string
+	var cur strings.Builder
+	curWidth := 0
+	for _, word := range strings.Split(line, " ") {
+		ww := visibleWidth(word)
+		if curWidth > 0 && curWidth+1+ww > width {
+			lines = append(lines, cur.String())
+			cur.Reset()
+			curWidth = 0
+		}
+		if cur.Len() > 0 {
+			cur.WriteByte(' ')
+			curWidth++
+		}
+		cur.WriteString(word)
+		curWidth += ww
+	}
+	return append(lines, cur.String())
+}
+
+// visibleWidth is the number of runes in s outside ANSI sequences.
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(ansiSequence.ReplaceAllString(s, ""))
+}
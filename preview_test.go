@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPreviewMatchesMerge(t *testing.T) {
+	dir := writeTree(t, t.TempDir(), mergeTree)
+	if _, stderr, code := runCLI(t, dir, "-out=p/prompt.md", "-no-log", "-strip-front-matter"); code != 0 {
+		t.Fatalf("merge: exit code %d:\n%s", code, stderr)
+	}
+	merged, err := os.ReadFile(filepath.Join(dir, "p", "prompt.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Not on a terminal, the preview is the merged text after a summary
+	// line and a rule.
+	stdout, stderr, code := runCLI(t, dir, "preview", "-out=p/prompt.md", "-strip-front-matter", "-width=40")
+	if code != 0 {
+		t.Fatalf("preview: exit code %d:\n%s", code, stderr)
+	}
+	summary, body, _ := strings.Cut(stdout, "\n")
+	if !strings.HasPrefix(summary, "2 files | ") {
+		t.Errorf("summary = %q, want 2 files", summary)
+	}
+	rule, body, _ := strings.Cut(body, "\n")
+	if rule != strings.Repeat("─", 40) {
+		t.Errorf("rule = %q", rule)
+	}
+	if want := strings.TrimSuffix(string(merged), "\n") + "\n"; body != want {
+		t.Errorf("preview = %q, want %q", body, want)
+	}
+}
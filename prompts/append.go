@@ -0,0 +1,166 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// MergeMode selects how Append treats an existing merged document.
+type MergeMode string
+
+const (
+	// ModeFull regenerates the whole document.
+	ModeFull MergeMode = "full"
+
+	// ModeAppend adds files that are not yet in the document to its end
+	// and leaves the sections of files already present untouched.
+	ModeAppend MergeMode = "append"
+
+	// ModeAppendUpdate is ModeAppend, but also replaces the sections of
+	// files whose content has changed.
+	ModeAppendUpdate MergeMode = "append-update"
+)
+
+var (
+	// ErrUnknownMergeMode is returned for a mode name that is not one of
+	// the MergeMode constants.
+	ErrUnknownMergeMode = errors.New("prompts: unknown merge mode")
+
+	// ErrAppendUnsupported is returned by Append for options whose output
+	// cannot be extended in place.
+	ErrAppendUnsupported = errors.New("prompts: option not supported when appending")
+)
+
+// ParseMergeMode converts a mode name such as "append" to a MergeMode.
+func ParseMergeMode(s string) (MergeMode, error) {
+	switch m := MergeMode(s); m {
+	case ModeFull, ModeAppend, ModeAppendUpdate:
+		return m, nil
+	case "":
+		return ModeFull, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownMergeMode, s)
+}
+
+// Append merges the files under dirs into existing, a document previously
+// produced by Merge with DefaultHeaderTemplate, locating the files already
+// present by their source headers. Files not present are appended at the
+// end with a header. A present file whose content differs from its section
+// is skipped with a warning under ModeAppend and rewritten in place under
+// ModeAppendUpdate. Sections of files that no longer exist are kept.
+// ModeFull ignores existing and behaves like Merge with headers enabled.
+//
+// TOC, Dedup, MaxChars, Sections and formats other than FormatMarkdown need
+// the whole document regenerated and make Append fail with ErrAppendUnsupported.
+func Append(existing []byte, dirs []string, opts MergeOptions, mode MergeMode) (*Result, error) {
+	opts.HeaderTemplate = DefaultHeaderTemplate
+	opts.CacheFile = ""
+	if mode == ModeFull {
+		return Merge(dirs, opts)
+	}
+	switch {
+	case opts.TOC:
+		return nil, fmt.Errorf("%w: table of contents", ErrAppendUnsupported)
+	case opts.Dedup:
+		return nil, fmt.Errorf("%w: dedup", ErrAppendUnsupported)
+	case opts.MaxChars > 0:
+		return nil, fmt.Errorf("%w: character limit", ErrAppendUnsupported)
+	case len(opts.Sections) > 0:
+		return nil, fmt.Errorf("%w: sections", ErrAppendUnsupported)
+	case opts.Format != "" && opts.Format != FormatMarkdown:
+		return nil, fmt.Errorf("%w: format %s", ErrAppendUnsupported, opts.Format)
+	}
+
+	spans, err := locateSections(existing)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]int, len(spans))
+	for i, sp := range spans {
+		present[filepath.FromSlash(sp.Path)] = i
+	}
+
+	files, err := collectFiles(dirs, opts)
+	if err != nil {
+		return nil, err
+	}
+	all, err := loadDocuments(files, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	docs := selectDocuments(all, opts)
+
+	replace := make([]*document, len(spans))
+	var added []*document
+	for _, doc := range docs {
+		i, ok := present[doc.Path]
+		if !ok {
+			added = append(added, doc)
+			continue
+		}
+		sp := spans[i]
+		old := bytes.TrimSuffix(existing[sp.Content:sp.End], []byte(opts.Separator))
+		if bytes.Equal(old, doc.Text) {
+			continue
+		}
+		if mode != ModeAppendUpdate {
+			if opts.Logger != nil {
+				opts.Logger.Warn("skipping modified file", "file", doc.Path)
+			}
+			continue
+		}
+		replace[i] = doc
+	}
+
+	// Sections are replaced back to front so that the offsets of earlier
+	// ones stay valid.
+	merged := bytes.Clone(existing)
+	for i := len(spans) - 1; i >= 0; i-- {
+		if doc := replace[i]; doc != nil {
+			sp := spans[i]
+			text := append(bytes.Clone(doc.Text), opts.Separator...)
+			merged = append(merged[:sp.Content], append(text, merged[sp.End:]...)...)
+		}
+	}
+
+	var buf bytes.Buffer
+	if len(added) > 0 {
+		if len(merged) > 0 && merged[len(merged)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+		if _, err := writeDocuments(&buf, added, opts); err != nil {
+			return nil, err
+		}
+	}
+	merged = append(merged, buf.Bytes()...)
+
+	res := &Result{Content: merged, streamThreshold: opts.StreamThreshold}
+	included := make(map[*document]bool, len(docs))
+	for _, sp := range spans {
+		res.Files = append(res.Files, sp.Path)
+	}
+	for _, doc := range docs {
+		if _, ok := present[doc.Path]; ok {
+			included[doc] = true
+		}
+	}
+	for _, doc := range added {
+		res.Files = append(res.Files, doc.Path)
+		included[doc] = true
+	}
+	for _, doc := range all {
+		if doc.normalized {
+			res.Normalized++
+		}
+		res.Sources = append(res.Sources, SourceRecord{
+			Path:     doc.Path,
+			SHA256:   doc.SHA256,
+			Bytes:    doc.Size,
+			Title:    doc.FrontMatter.Title,
+			Included: included[doc],
+		})
+	}
+	return res, nil
+}
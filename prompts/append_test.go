@@ -0,0 +1,95 @@
+package prompts
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestAppendExisting(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"p/1.md": "# One\n",
+		"p/2.md": "# Two\n",
+	})
+	opts := DefaultMergeOptions()
+	base, err := Append(nil, []string{"p"}, opts, ModeFull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := base.Content
+
+	writeFiles(t, map[string]string{
+		"p/0.md": "# Zero\n",
+		"p/2.md": "# Two, edited\n",
+	})
+	if err := os.Remove("p/1.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	one := "<!-- source: p/1.md -->\n# One\n\n"
+	zero := "<!-- source: p/0.md -->\n# Zero\n\n"
+	tests := []struct {
+		mode  MergeMode
+		want  string
+		files []string
+	}{
+		// Removed files keep their sections and new ones go at the end,
+		// whatever the sort order says.
+		{ModeAppend, one + "<!-- source: p/2.md -->\n# Two\n\n" + zero, []string{"p/1.md", "p/2.md", "p/0.md"}},
+		{ModeAppendUpdate, one + "<!-- source: p/2.md -->\n# Two, edited\n\n" + zero, []string{"p/1.md", "p/2.md", "p/0.md"}},
+	}
+	for _, tt := range tests {
+		res, err := Append(existing, []string{"p"}, opts, tt.mode)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.mode, err)
+		}
+		if string(res.Content) != tt.want {
+			t.Errorf("%s: content = %q, want %q", tt.mode, res.Content, tt.want)
+		}
+		var files []string
+		for _, f := range res.Files {
+			files = append(files, filepath.ToSlash(f))
+		}
+		if !reflect.DeepEqual(files, tt.files) {
+			t.Errorf("%s: files = %q, want %q", tt.mode, files, tt.files)
+		}
+	}
+
+	// Appending to the result again adds nothing.
+	res, err := Append([]byte(tests[1].want), []string{"p"}, opts, ModeAppend)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Content) != tests[1].want {
+		t.Errorf("second append changed the document to %q", res.Content)
+	}
+}
+
+func TestAppendUnsupported(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/1.md": "# One\n"})
+	tests := map[string]func(*MergeOptions){
+		"toc":       func(o *MergeOptions) { o.TOC = true },
+		"dedup":     func(o *MergeOptions) { o.Dedup = true },
+		"max chars": func(o *MergeOptions) { o.MaxChars = 10 },
+		"sections":  func(o *MergeOptions) { o.Sections = []string{"## Examples"} },
+		"json":      func(o *MergeOptions) { o.Format = FormatJSON },
+	}
+	existing := []byte("<!-- source: p/1.md -->\n# One\n\n")
+	for name, set := range tests {
+		opts := DefaultMergeOptions()
+		set(&opts)
+		for _, mode := range []MergeMode{ModeAppend, ModeAppendUpdate} {
+			if _, err := Append(existing, []string{"p"}, opts, mode); !errors.Is(err, ErrAppendUnsupported) {
+				t.Errorf("%s, %s: err = %v, want ErrAppendUnsupported", name, mode, err)
+			}
+		}
+		// A full merge regenerates the document and supports everything.
+		if _, err := Append(existing, []string{"p"}, opts, ModeFull); err != nil {
+			t.Errorf("%s, full: %v", name, err)
+		}
+	}
+}
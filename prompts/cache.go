@@ -0,0 +1,200 @@
+package prompts
+
+import (
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCacheFile is the cache path used by the go-prompts command.
+const DefaultCacheFile = ".prompt-cache.json"
+
+const cacheVersion = 1
+
+// cacheFile is the on-disk form of the merge cache. It remembers, for each
+// source file, the stat data and hash seen last time along with where the
+// file's content landed in the previous output. A file whose modification
+// time and size are unchanged is then taken from that slice of the old
+// output instead of being read and hashed again.
+type cacheFile struct {
+	Version      int          `json:"version"`
+	Options      string       `json:"options"`
+	Output       string       `json:"output"`
+	OutputSHA256 string       `json:"output_sha256"`
+	Files        []cacheEntry `json:"files"`
+}
+
+type cacheEntry struct {
+	Path        string      `json:"path"`
+	ModTime     time.Time   `json:"mtime"`
+	Size        int64       `json:"size"`
+	SHA256      string      `json:"sha256"`
+	Offset      int         `json:"offset_in_output"`
+	Length      int         `json:"length"`
+	FrontMatter FrontMatter `json:"front_matter"`
+	Normalized  bool        `json:"normalized,omitempty"`
+}
+
+// mergeCache is the cache state of a single merge: what was loaded from
+// the previous run and what will be saved for the next one.
+type mergeCache struct {
+	path    string
+	options string
+	prev    map[string]cacheEntry
+	output  []byte
+	next    []cacheEntry
+}
+
+// cacheable reports whether outputs produced with opts can be cached.
+// Encoded formats do not contain file contents verbatim, and deduplication
+// and truncation make a file's output depend on the files around it.
+func cacheable(opts MergeOptions) bool {
+	return opts.CacheFile != "" && opts.Source == nil && !opts.Template && (opts.Format == "" || opts.Format == FormatMarkdown) &&
+		!opts.Dedup && opts.MaxChars <= 0 && len(opts.Sections) == 0
+}
+
+// loadCache reads the cache for opts. A missing or unusable cache, or one
+// written with different options or for an output that has changed since,
+// yields an empty cache rather than an error.
+func loadCache(opts MergeOptions) *mergeCache {
+	c := &mergeCache{path: opts.CacheFile, options: optionsFingerprint(opts)}
+
+	data, err := os.ReadFile(opts.CacheFile)
+	if err != nil {
+		return c
+	}
+	var cf cacheFile
+	if err := json.Unmarshal(data, &cf); err != nil || cf.Version != cacheVersion || cf.Options != c.options {
+		return c
+	}
+	output, err := readOutput(cf.Output)
+	if err != nil || sha256Hex(output) != cf.OutputSHA256 {
+		return c
+	}
+
+	c.output = output
+	c.prev = make(map[string]cacheEntry, len(cf.Files))
+	for _, e := range cf.Files {
+		c.prev[e.Path] = e
+	}
+	return c
+}
+
+// readOutput reads the output a cache was saved for, decompressing it if
+// it is a gzip file written by Result.WriteCompressed.
+func readOutput(path string) ([]byte, error) {
+	if !strings.HasSuffix(path, ".gz") {
+		return os.ReadFile(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+// lookup returns the cached entry for path and its content if the file's
+// stat data still matches.
+func (c *mergeCache) lookup(path string, info os.FileInfo) (cacheEntry, []byte, bool) {
+	if c == nil {
+		return cacheEntry{}, nil, false
+	}
+	e, ok := c.prev[path]
+	if !ok || !e.ModTime.Equal(info.ModTime()) || e.Size != info.Size() {
+		return cacheEntry{}, nil, false
+	}
+	if e.FrontMatter.Disabled {
+		return e, nil, true
+	}
+	if e.Offset < 0 || e.Offset+e.Length > len(c.output) {
+		return cacheEntry{}, nil, false
+	}
+	return e, c.output[e.Offset : e.Offset+e.Length], true
+}
+
+// save writes the cache for output, which was just written to outputPath,
+// compressed if outputPath ends in ".gz".
+func (c *mergeCache) save(outputPath string, output []byte) error {
+	cf := cacheFile{
+		Version:      cacheVersion,
+		Options:      c.options,
+		Output:       outputPath,
+		OutputSHA256: sha256Hex(output),
+		Files:        c.next,
+	}
+	data, err := json.MarshalIndent(cf, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o666)
+}
+
+// optionsFingerprint hashes every option that affects the merged output,
+// so that changing any of them invalidates the whole cache.
+func optionsFingerprint(opts MergeOptions) string {
+	key := struct {
+		Extensions       []string
+		Include, Exclude []string
+		Skip             []string
+		Separator        string
+		Sort             bool
+		SortMode         SortMode
+		Order            []string
+		MaxFileSize      int64
+		Tags             []string
+		RequireTags      bool
+		StripFrontMatter bool
+		Normalize        bool
+		SortByPriority   bool
+		TOC              bool
+		HeaderTemplate   string
+		ExpandEnv        bool
+		Vars             map[string]string
+		StrictVars       bool
+		Environ          []string
+		Format           Format
+	}{
+		Extensions:       opts.Extensions,
+		Include:          opts.Include,
+		Exclude:          opts.Exclude,
+		Skip:             opts.Skip,
+		Separator:        opts.Separator,
+		Sort:             opts.Sort,
+		SortMode:         opts.SortMode,
+		Order:            opts.Order,
+		MaxFileSize:      opts.MaxFileSize,
+		Tags:             opts.Tags,
+		RequireTags:      opts.RequireTags,
+		StripFrontMatter: opts.StripFrontMatter,
+		Normalize:        opts.Normalize,
+		SortByPriority:   opts.SortByPriority,
+		TOC:              opts.TOC,
+		HeaderTemplate:   opts.HeaderTemplate,
+		ExpandEnv:        opts.ExpandEnv,
+		Vars:             opts.Vars,
+		StrictVars:       opts.StrictVars,
+		Format:           opts.Format,
+	}
+	if opts.ExpandEnv {
+		key.Environ = os.Environ()
+		sort.Strings(key.Environ)
+	}
+	data, _ := json.Marshal(key)
+	return sha256Hex(data)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
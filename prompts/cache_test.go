@@ -0,0 +1,114 @@
+package prompts
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+// cachedMerge merges p with the cache and writes prompt.md.
+func cachedMerge(t *testing.T) string {
+	t.Helper()
+	opts := DefaultMergeOptions()
+	opts.CacheFile = DefaultCacheFile
+	res, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.WriteFile("prompt.md"); err != nil {
+		t.Fatal(err)
+	}
+	return string(res.Content)
+}
+
+func TestCacheHit(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/a.md": "# A\n", "p/b.md": "# B\n"})
+	cachedMerge(t)
+
+	// With the modification time and size unchanged the file is not read
+	// again, so an edit that keeps both goes unnoticed.
+	writeFiles(t, map[string]string{"p/a.md": "# Z\n"})
+	if got, want := cachedMerge(t), "# A\n\n# B\n\n"; got != want {
+		t.Errorf("merge = %q, want the cached %q", got, want)
+	}
+}
+
+func TestCacheInvalidation(t *testing.T) {
+	tests := map[string]func(t *testing.T){
+		"size": func(t *testing.T) {
+			writeFiles(t, map[string]string{"p/a.md": "# A, edited\n"})
+		},
+		"modification time": func(t *testing.T) {
+			writeFiles(t, map[string]string{"p/a.md": "# Z\n"})
+			later := fixtureTime.Add(time.Second)
+			if err := os.Chtimes("p/a.md", later, later); err != nil {
+				t.Fatal(err)
+			}
+		},
+		"output": func(t *testing.T) {
+			writeFiles(t, map[string]string{"p/a.md": "# Z\n"})
+			if err := os.WriteFile("prompt.md", []byte("# A\n\n# B, edited\n\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		},
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			inTempDir(t)
+			writeFiles(t, map[string]string{"p/a.md": "# A\n", "p/b.md": "# B\n"})
+			cachedMerge(t)
+			change(t)
+			content, _ := os.ReadFile("p/a.md")
+			if got, want := cachedMerge(t), string(content)+"\n# B\n\n"; got != want {
+				t.Errorf("merge = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCacheCorrupt(t *testing.T) {
+	tests := map[string]func(t *testing.T, cache []byte) []byte{
+		"not json":      func(*testing.T, []byte) []byte { return []byte("{not json") },
+		"wrong version": func(*testing.T, []byte) []byte { return []byte(`{"version": 99}`) },
+		"bad offsets": func(t *testing.T, cache []byte) []byte {
+			var cf cacheFile
+			if err := json.Unmarshal(cache, &cf); err != nil {
+				t.Fatal(err)
+			}
+			for i := range cf.Files {
+				cf.Files[i].Offset = 1 << 20
+			}
+			data, _ := json.Marshal(cf)
+			return data
+		},
+	}
+	for name, corrupt := range tests {
+		t.Run(name, func(t *testing.T) {
+			inTempDir(t)
+			writeFiles(t, map[string]string{"p/a.md": "# A\n", "p/b.md": "# B\n"})
+			want := cachedMerge(t)
+			cache, err := os.ReadFile(DefaultCacheFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(DefaultCacheFile, corrupt(t, cache), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := cachedMerge(t); got != want {
+				t.Errorf("merge = %q, want %q", got, want)
+			}
+			// The merge replaced the cache with a usable one.
+			cache, err = os.ReadFile(DefaultCacheFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var cf cacheFile
+			if err := json.Unmarshal(cache, &cf); err != nil || cf.Version != cacheVersion || len(cf.Files) != 2 {
+				t.Errorf("cache after recovery = %s (%v)", cache, err)
+			}
+		})
+	}
+}
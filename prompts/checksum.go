@@ -0,0 +1,90 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrChecksumMismatch is returned by VerifyChecksum when a file no longer
+// matches its recorded checksum.
+var ErrChecksumMismatch = errors.New("prompts: checksum mismatch")
+
+// ComputeChecksum returns the hex-encoded SHA-256 of everything read from r.
+func ComputeChecksum(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChecksumPath returns the checksum file path for outputPath, which is
+// outputPath with ".sha256" appended.
+func ChecksumPath(outputPath string) string {
+	return outputPath + ".sha256"
+}
+
+// WriteChecksum records the checksum of r's content in path, in the format
+// of sha256sum so that "sha256sum -c" can check it as well. name is the
+// output file the checksum is for.
+func (r *Result) WriteChecksum(path, name string) error {
+	line := fmt.Sprintf("%s  %s\n", r.contentSHA256(), filepath.Base(name))
+	if err := os.WriteFile(path, []byte(line), 0o666); err != nil {
+		return fmt.Errorf("prompts: write checksum %s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteFileChecksum records the checksum of the file at path in
+// checksumPath, in the format of Result.WriteChecksum.
+func WriteFileChecksum(path, checksumPath string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("prompts: open %s: %w", path, err)
+	}
+	defer f.Close()
+	sum, err := ComputeChecksum(f)
+	if err != nil {
+		return fmt.Errorf("prompts: read %s: %w", path, err)
+	}
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(path))
+	if err := os.WriteFile(checksumPath, []byte(line), 0o666); err != nil {
+		return fmt.Errorf("prompts: write checksum %s: %w", checksumPath, err)
+	}
+	return nil
+}
+
+// VerifyChecksum checks the file at path against the checksum recorded in
+// checksumPath, returning an error wrapping ErrChecksumMismatch if they
+// differ.
+func VerifyChecksum(path, checksumPath string) error {
+	data, err := os.ReadFile(checksumPath)
+	if err != nil {
+		return fmt.Errorf("prompts: read checksum %s: %w", checksumPath, err)
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return fmt.Errorf("prompts: %s: no checksum", checksumPath)
+	}
+	want := strings.ToLower(fields[0])
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("prompts: open %s: %w", path, err)
+	}
+	defer f.Close()
+	got, err := ComputeChecksum(f)
+	if err != nil {
+		return fmt.Errorf("prompts: read %s: %w", path, err)
+	}
+	if got != want {
+		return fmt.Errorf("%w: %s has SHA-256 %s, %s records %s", ErrChecksumMismatch, path, got, checksumPath, want)
+	}
+	return nil
+}
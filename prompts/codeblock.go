@@ -0,0 +1,78 @@
+package prompts
+
+import (
+	"bytes"
+	"path"
+	"strings"
+)
+
+// codeLanguages maps the extensions of data files, which Merge embeds as
+// fenced code blocks rather than as Markdown, to the language of the
+// fence.
+var codeLanguages = map[string]string{
+	".json": "json",
+	".yaml": "yaml",
+	".yml":  "yaml",
+}
+
+// codeLanguage returns the fence language for the file at p, or "" if it
+// is merged as Markdown.
+func codeLanguage(p string) string {
+	return codeLanguages[strings.ToLower(path.Ext(p))]
+}
+
+// WrapAsCodeBlock returns content as a fenced Markdown code block tagged
+// with lang, such as "json". The fence is longer than any run of backticks
+// in content, so that content cannot close it early.
+func WrapAsCodeBlock(content []byte, lang string) []byte {
+	longest, run := 0, 0
+	for _, c := range content {
+		if c == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+
+	var b bytes.Buffer
+	b.WriteString(fence + lang + "\n")
+	b.Write(content)
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		b.WriteByte('\n')
+	}
+	b.WriteString(fence + "\n")
+	return b.Bytes()
+}
+
+// codeCaption returns the comment placed above the code block of a data
+// file, naming the file.
+func codeCaption(file string) []byte {
+	name := path.Base(strings.ReplaceAll(file, "\\", "/"))
+	return []byte("<!-- " + strings.ReplaceAll(name, "--", "- -") + " -->\n")
+}
+
+// unwrapCodeBlock reverses the wrapping Merge applies to the data file at
+// file, returning the file's content without its caption and code fence.
+// Content that is not wrapped as expected is returned unchanged.
+func unwrapCodeBlock(file string, content []byte) []byte {
+	lang := codeLanguage(file)
+	if lang == "" {
+		return content
+	}
+	rest, ok := bytes.CutPrefix(content, codeCaption(file))
+	if !ok {
+		return content
+	}
+	open, rest, ok := bytes.Cut(rest, []byte("\n"))
+	fence := bytes.TrimSuffix(open, []byte(lang))
+	if !ok || len(fence) < 3 || len(bytes.Trim(fence, "`")) != 0 || len(fence) == len(open) {
+		return content
+	}
+	body, ok := bytes.CutSuffix(rest, append(bytes.Clone(fence), '\n'))
+	if !ok || len(body) > 0 && body[len(body)-1] != '\n' {
+		return content
+	}
+	return body
+}
@@ -0,0 +1,100 @@
+package prompts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeDataFileKeepsDocumentMarkers(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"p/multi.yaml": "---\na: 1\n---\nb: 2\n",
+		"p/bad.yml":    "---\n: [\n---\n",
+	})
+	opts := DefaultMergeOptions()
+	opts.StripFrontMatter = true
+	res, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<!-- bad.yml -->\n```yaml\n---\n: [\n---\n```\n\n" +
+		"<!-- multi.yaml -->\n```yaml\n---\na: 1\n---\nb: 2\n```\n\n"
+	if got := string(res.Content); got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+}
+
+func TestMergeSkipsSidecarFiles(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"a.md":            "# A\n",
+		"prompt.md":       "# Old output\n",
+		"prompt.log.json": "{}\n",
+		"schema.json":     "{}\n",
+	})
+	opts := DefaultMergeOptions()
+	opts.CacheFile = DefaultCacheFile
+	opts.Skip = []string{"prompt.md", "./prompt.log.json"}
+	for i := 0; i < 2; i++ {
+		// The second run finds the cache the first one wrote.
+		res, err := Merge([]string{"."}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"a.md", "schema.json"}; !reflect.DeepEqual(res.Files, want) {
+			t.Fatalf("run %d: files = %q, want %q", i+1, res.Files, want)
+		}
+		if err := res.WriteFile("prompt.md"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSplitMergedUnwrapsDataFiles(t *testing.T) {
+	inTempDir(t)
+	files := map[string]string{
+		"p/a.md":        "---\ntitle: A\n---\n# A\n",
+		"p/schema.json": "{\"type\": \"object\"}\n",
+		"p/fenced.yaml": "doc: |\n  ```go\n  x := 1\n  ```\n",
+	}
+	writeFiles(t, files)
+	opts := DefaultMergeOptions()
+	opts.HeaderTemplate = DefaultHeaderTemplate
+	res, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, err := SplitMerged(res.Content, opts.Separator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != len(files) {
+		t.Fatalf("got %d sections, want %d", len(sections), len(files))
+	}
+	for _, s := range sections {
+		if want := files[s.Path]; string(s.Content) != want {
+			t.Errorf("%s = %q, want %q", s.Path, s.Content, want)
+		}
+	}
+}
+
+func TestUnwrapCodeBlock(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"not wrapped\n",
+		"<!-- x.json -->\n```yaml\n{}\n```\n",
+		"<!-- x.json -->\n```json\n{}\n",
+	} {
+		if got := string(unwrapCodeBlock("x.json", []byte(content))); got != content {
+			t.Errorf("unwrapCodeBlock(%q) = %q, want it unchanged", content, got)
+		}
+	}
+	wrapped := string(codeCaption("x.json")) + string(WrapAsCodeBlock([]byte("{}"), "json"))
+	if got := string(unwrapCodeBlock("x.json", []byte(wrapped))); got != "{}\n" {
+		t.Errorf("unwrapCodeBlock(%q) = %q, want %q", wrapped, got, "{}\n")
+	}
+	if got := string(unwrapCodeBlock("x.md", []byte(wrapped))); !strings.HasPrefix(got, "<!--") {
+		t.Errorf("unwrapCodeBlock of a Markdown file = %q, want it unchanged", got)
+	}
+}
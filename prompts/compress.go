@@ -0,0 +1,103 @@
+package prompts
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultCompressLevel is the gzip level used by the command line tool,
+// the level gzip.DefaultCompression currently stands for.
+const DefaultCompressLevel = 6
+
+// CompressStats describes a compressed copy of a merged file.
+type CompressStats struct {
+	Path              string  `json:"path"`
+	UncompressedBytes int     `json:"uncompressed_bytes"`
+	CompressedBytes   int     `json:"compressed_bytes"`
+	Ratio             float64 `json:"ratio"` // compressed size over uncompressed size
+}
+
+// CompressOutput writes a gzip-compressed copy of the file src to dst at
+// the given level, from gzip.BestSpeed to gzip.BestCompression.
+func CompressOutput(src, dst string, level int) (CompressStats, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return CompressStats{}, fmt.Errorf("prompts: read %s: %w", src, err)
+	}
+	defer f.Close()
+	return compress(f, filepath.Base(src), dst, level)
+}
+
+// WriteCompressed writes r's content gzip-compressed to path, as
+// CompressOutput does, and records the result for Log. Unless WriteFile
+// has already done so, it updates the cache for path, so that a merge
+// written only compressed is still cached.
+func (r *Result) WriteCompressed(path string, level int) (CompressStats, error) {
+	content, err := r.openContent()
+	if err != nil {
+		return CompressStats{}, err
+	}
+	defer content.Close()
+	stats, err := compress(content, strings.TrimSuffix(filepath.Base(path), ".gz"), path, level)
+	if err != nil {
+		return stats, err
+	}
+	r.compressed = &stats
+	if !r.cacheSaved {
+		if err := r.saveCache(path); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+// compress copies src to dst as a gzip member called name. The header
+// carries no modification time, so the same data always compresses to the
+// same bytes.
+func compress(src io.Reader, name, dst string, level int) (stats CompressStats, err error) {
+	f, err := os.Create(dst)
+	if err != nil {
+		return CompressStats{}, fmt.Errorf("prompts: write %s: %w", dst, err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("prompts: write %s: %w", dst, cerr)
+		}
+	}()
+
+	out := &countingWriter{w: f}
+	zw, err := gzip.NewWriterLevel(out, level)
+	if err != nil {
+		return CompressStats{}, fmt.Errorf("prompts: compress %s: %w", dst, err)
+	}
+	zw.Name = name
+	n, err := io.Copy(zw, src)
+	if err != nil {
+		return CompressStats{}, fmt.Errorf("prompts: compress %s: %w", dst, err)
+	}
+	if err := zw.Close(); err != nil {
+		return CompressStats{}, fmt.Errorf("prompts: compress %s: %w", dst, err)
+	}
+
+	stats = CompressStats{Path: dst, UncompressedBytes: int(n), CompressedBytes: int(out.n)}
+	if n > 0 {
+		stats.Ratio = float64(out.n) / float64(n)
+	}
+	return stats, nil
+}
+
+// countingWriter counts the bytes written through it to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
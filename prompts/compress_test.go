@@ -0,0 +1,133 @@
+package prompts
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+)
+
+// gunzip returns the decompressed content of the gzip file at path and the
+// name in its header.
+func gunzip(t *testing.T, path string) ([]byte, string) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := zr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return data, zr.Name
+}
+
+func TestWriteCompressed(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, reproducibleFixture)
+	res, err := Merge([]string{"general", "libs"}, DefaultMergeOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first []byte
+	for _, level := range []int{gzip.BestSpeed, DefaultCompressLevel, gzip.BestCompression} {
+		stats, err := res.WriteCompressed("prompt.md.gz", level)
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		data, name := gunzip(t, "prompt.md.gz")
+		if !bytes.Equal(data, res.Content) {
+			t.Errorf("level %d: decompressed %q, want %q", level, data, res.Content)
+		}
+		if name != "prompt.md" {
+			t.Errorf("level %d: gzip name = %q, want prompt.md", level, name)
+		}
+		if stats.UncompressedBytes != len(res.Content) {
+			t.Errorf("level %d: uncompressed bytes = %d, want %d", level, stats.UncompressedBytes, len(res.Content))
+		}
+		if info, err := os.Stat("prompt.md.gz"); err != nil || info.Size() != int64(stats.CompressedBytes) {
+			t.Errorf("level %d: compressed bytes = %d, file is %v (%v)", level, stats.CompressedBytes, info.Size(), err)
+		}
+		if level == DefaultCompressLevel {
+			first, _ = os.ReadFile("prompt.md.gz")
+		}
+	}
+
+	// The same content always compresses to the same bytes.
+	if err := os.Mkdir("again", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := res.WriteCompressed("again/prompt.md.gz", DefaultCompressLevel); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := os.ReadFile("again/prompt.md.gz"); !bytes.Equal(again, first) {
+		t.Error("compressing twice gave different bytes")
+	}
+}
+
+func TestCompressOutput(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"prompt.md": "# Prompt\n\nSome text.\n"})
+	if _, err := CompressOutput("prompt.md", "out.gz", DefaultCompressLevel); err != nil {
+		t.Fatal(err)
+	}
+	data, name := gunzip(t, "out.gz")
+	if string(data) != "# Prompt\n\nSome text.\n" || name != "prompt.md" {
+		t.Errorf("decompressed %q named %q", data, name)
+	}
+	if _, err := CompressOutput("prompt.md", "out.gz", 42); err == nil {
+		t.Error("compressing at level 42 succeeded")
+	}
+}
+
+func TestWriteCompressedCache(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/1.md": "# One\n", "p/2.md": "# Two\n"})
+	opts := DefaultMergeOptions()
+	opts.CacheFile = DefaultCacheFile
+	res, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := res.WriteCompressed("prompt.md.gz", DefaultCompressLevel); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next merge takes the files from the compressed output.
+	cache := loadCache(opts)
+	if len(cache.prev) != 2 || !bytes.Equal(cache.output, res.Content) {
+		t.Fatalf("cache has %d files and output %q, want 2 and %q", len(cache.prev), cache.output, res.Content)
+	}
+	again, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Content, res.Content) {
+		t.Errorf("cached merge = %q, want %q", again.Content, res.Content)
+	}
+
+	// Once the plain output is written too, the cache refers to it.
+	if err := again.WriteFile("prompt.md"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := again.WriteCompressed("prompt.md.gz", DefaultCompressLevel); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(DefaultCacheFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"output": "prompt.md"`)) {
+		t.Errorf("cache does not refer to prompt.md:\n%s", data)
+	}
+}
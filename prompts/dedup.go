@@ -0,0 +1,94 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+// DeduplicateContent removes every paragraph of sections that already
+// appeared, after trimming surrounding whitespace, in an earlier section.
+// Paragraphs are separated by blank lines; a fenced code block counts as a
+// single paragraph even if it contains blank lines. Paragraphs without any
+// letters or digits, such as "---" rules, are never removed. The result has
+// one entry per input section.
+func DeduplicateContent(sections []string) []string {
+	return deduplicate(sections, nil, nil)
+}
+
+// deduplicate implements DeduplicateContent. When logger is set, each
+// removal is logged at debug level naming the section, from names, that
+// first introduced the paragraph and the one it was removed from.
+func deduplicate(sections, names []string, logger *slog.Logger) []string {
+	seen := map[[sha256.Size]byte]int{}
+	name := func(i int) string {
+		if i < len(names) {
+			return names[i]
+		}
+		return ""
+	}
+
+	out := make([]string, len(sections))
+	for i, section := range sections {
+		paragraphs := splitParagraphs(section)
+		kept := paragraphs[:0]
+		local := map[[sha256.Size]byte]bool{}
+		for _, p := range paragraphs {
+			trimmed := strings.TrimSpace(p)
+			if !strings.ContainsFunc(trimmed, isWordRune) {
+				kept = append(kept, p)
+				continue
+			}
+			sum := sha256.Sum256([]byte(trimmed))
+			if first, ok := seen[sum]; ok {
+				if logger != nil {
+					logger.Debug("removed duplicate paragraph",
+						"file", name(i), "first_seen", name(first))
+				}
+				continue
+			}
+			local[sum] = true
+			kept = append(kept, p)
+		}
+		for sum := range local {
+			seen[sum] = i
+		}
+		joined := strings.Join(kept, "\n\n")
+		if joined != "" && strings.HasSuffix(section, "\n") && !strings.HasSuffix(joined, "\n") {
+			joined += "\n"
+		}
+		out[i] = joined
+	}
+	return out
+}
+
+// splitParagraphs splits text on blank lines without breaking fenced code
+// blocks apart. Joining the result with "\n\n" restores text.
+func splitParagraphs(text string) []string {
+	parts := strings.Split(text, "\n\n")
+	var out []string
+	for i := 0; i < len(parts); i++ {
+		p := parts[i]
+		for fenceCount(p)%2 == 1 && i+1 < len(parts) {
+			i++
+			p += "\n\n" + parts[i]
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// fenceCount returns the number of code fence lines in text.
+func fenceCount(text string) int {
+	n := 0
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			n++
+		}
+	}
+	return n
+}
+
+func isWordRune(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
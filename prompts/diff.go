@@ -0,0 +1,217 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// UnifiedDiff returns the differences between old and new as a unified
+// diff with three lines of context, or "" if they are equal. oldName and
+// newName label the two sides in the diff header.
+func UnifiedDiff(oldName, newName string, old, new []byte) string {
+	if string(old) == string(new) {
+		return ""
+	}
+	a, b := splitLines(string(old)), splitLines(string(new))
+	ops := diffLines(a, b)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	for i := 0; i < len(ops); {
+		// Skip to the next change.
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := max(i-diffContext, 0)
+		end := i
+		// Extend the hunk while changes are close enough to share context.
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(end+diffContext, len(ops))
+				break
+			}
+			end = run
+		}
+
+		aStart, bStart := ops[start].a, ops[start].b
+		aLen, bLen := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aLen++
+			}
+			if op.kind != '-' {
+				bLen++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aStart, aLen), hunkRange(bStart, bLen))
+		for _, op := range ops[start:end] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+func hunkRange(start, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if n == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, n)
+}
+
+// diffOp is one line of an edit script: kept (' '), removed ('-') or added
+// ('+'). a and b are the line's indexes in the old and new input, or where
+// it would be for lines absent on that side.
+type diffOp struct {
+	kind byte
+	line string
+	a, b int
+}
+
+// splitLines splits s after each newline, keeping the newlines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script from a to b with the
+// linear-space variant of Myers' O(ND) algorithm, which splits the inputs
+// at the middle snake of an optimal path and recurses on either side. It
+// uses O(N+M) memory however different the inputs are.
+func diffLines(a, b []string) []diffOp {
+	d := &differ{a: a, b: b}
+	d.compare(0, len(a), 0, len(b))
+	return d.ops
+}
+
+// differ accumulates the edit script of diffLines.
+type differ struct {
+	a, b   []string
+	ops    []diffOp
+	vf, vb []int // furthest-reaching x per diagonal, forward and backward
+}
+
+// compare appends the edit script from a[aLo:aHi] to b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.ops = append(d.ops, diffOp{kind: ' ', line: d.a[aLo], a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			d.ops = append(d.ops, diffOp{kind: '+', line: d.b[y], a: aLo, b: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			d.ops = append(d.ops, diffOp{kind: '-', line: d.a[x], a: x, b: bLo})
+		}
+	default:
+		// Both sides are non-empty and differ at either end, so the edit
+		// distance is at least two and both halves are smaller problems.
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		for ; x < u; x, y = x+1, y+1 {
+			d.ops = append(d.ops, diffOp{kind: ' ', line: d.a[x], a: x, b: y})
+		}
+		d.compare(u, aHi, v, bHi)
+	}
+
+	for i := 0; i < suffix; i++ {
+		d.ops = append(d.ops, diffOp{kind: ' ', line: d.a[aHi+i], a: aHi + i, b: bHi + i})
+	}
+}
+
+// middleSnake returns the snake, from (x, y) to (u, v), in the middle of a
+// shortest edit path from a[aLo:aHi] to b[bLo:bHi]. It searches forward
+// from the start and backward from the end until the two meet.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	if size := 2*offset + 1; len(d.vf) < size {
+		d.vf = make([]int, size)
+		d.vb = make([]int, size)
+	}
+	vf, vb := d.vf, d.vb
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	for step := 0; step <= maxD; step++ {
+		for k := -step; k <= step; k += 2 {
+			var x int
+			if k == -step || k != step && vf[offset+k-1] < vf[offset+k+1] {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			sx, sy := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			// Diagonal k of the forward search is diagonal delta-k of
+			// the backward one, which has reached it in step-1 steps.
+			if c := delta - k; odd && c >= -(step-1) && c <= step-1 && x+vb[offset+c] >= n {
+				return aLo + sx, bLo + sy, aLo + x, bLo + y
+			}
+		}
+		for c := -step; c <= step; c += 2 {
+			// x and y count the lines matched from the ends.
+			var x int
+			if c == -step || c != step && vb[offset+c-1] < vb[offset+c+1] {
+				x = vb[offset+c+1]
+			} else {
+				x = vb[offset+c-1] + 1
+			}
+			y := x - c
+			sx, sy := x, y
+			for x < n && y < m && d.a[aHi-x-1] == d.b[bHi-y-1] {
+				x++
+				y++
+			}
+			vb[offset+c] = x
+			if k := delta - c; !odd && k >= -step && k <= step && x+vf[offset+k] >= n {
+				return aHi - x, bHi - y, aHi - sx, bHi - sy
+			}
+		}
+	}
+	panic("prompts: diff found no middle snake")
+}
@@ -0,0 +1,65 @@
+package prompts
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// lcsLen is the length of the longest common subsequence of a and b.
+func lcsLen(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(cur[j], prev[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randLines := func() []string {
+		lines := make([]string, rng.Intn(40))
+		for i := range lines {
+			lines[i] = string(rune('a'+rng.Intn(4))) + "\n"
+		}
+		return lines
+	}
+	for i := 0; i < 2000; i++ {
+		a, b := randLines(), randLines()
+		ops := diffLines(a, b)
+
+		var gotA, gotB []string
+		kept := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				if op.a != len(gotA) {
+					t.Fatalf("%q -> %q: op %+v at a=%d", a, b, op, len(gotA))
+				}
+				gotA = append(gotA, op.line)
+			}
+			if op.kind != '-' {
+				if op.b != len(gotB) {
+					t.Fatalf("%q -> %q: op %+v at b=%d", a, b, op, len(gotB))
+				}
+				gotB = append(gotB, op.line)
+			}
+			if op.kind == ' ' {
+				kept++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("%q -> %q: script does not reproduce the inputs", a, b)
+		}
+		if want := lcsLen(a, b); kept != want {
+			t.Fatalf("%q -> %q: kept %d lines, want %d", a, b, kept, want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package prompts
+
+import (
+	"bytes"
+	"regexp"
+)
+
+var splitDirective = regexp.MustCompile(`^<!--\s*split:\s*(\S+)\s*-->$`)
+
+// SplitDirectives divides content at "<!-- split: name -->" lines, each of
+// which routes the lines after it to the output called name until the next
+// directive. The result maps each name to its content, with the directive
+// lines removed; content before the first directive is under "". Content
+// routed to the same name more than once is concatenated. Directives
+// inside fenced code blocks are ordinary text.
+func SplitDirectives(content []byte) map[string][]byte {
+	parts := map[string][]byte{}
+	name := ""
+	inFence := false
+	for len(content) > 0 {
+		line, rest, found := bytes.Cut(content, []byte("\n"))
+		content = rest
+		trimmed := bytes.TrimSpace(line)
+		if bytes.HasPrefix(trimmed, []byte("```")) || bytes.HasPrefix(trimmed, []byte("~~~")) {
+			inFence = !inFence
+		}
+		if m := splitDirective.FindSubmatch(trimmed); m != nil && !inFence {
+			name = string(m[1])
+			if _, ok := parts[name]; !ok {
+				parts[name] = []byte{}
+			}
+			continue
+		}
+		parts[name] = append(parts[name], line...)
+		if found {
+			parts[name] = append(parts[name], '\n')
+		}
+	}
+	return parts
+}
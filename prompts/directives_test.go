@@ -0,0 +1,50 @@
+package prompts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitDirectives(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+	}{
+		{"no directives", "# A\n\ntext\n", map[string]string{"": "# A\n\ntext\n"}},
+		{
+			"routed",
+			"# Shared\n<!-- split: private.md -->\nsecret\n<!-- split: public.md -->\nhello\n",
+			map[string]string{"": "# Shared\n", "private.md": "secret\n", "public.md": "hello\n"},
+		},
+		{
+			"same name twice",
+			"<!-- split: b.md -->\none\n<!-- split: c.md -->\ntwo\n<!--split:b.md-->\nthree",
+			map[string]string{"b.md": "one\nthree", "c.md": "two\n"},
+		},
+		{"spaces and indentation", "  <!--   split:   b.md   -->  \nx\n", map[string]string{"b.md": "x\n"}},
+		{"empty section", "a\n<!-- split: b.md -->\n", map[string]string{"": "a\n", "b.md": ""}},
+		{
+			"inside a fence",
+			"```md\n<!-- split: b.md -->\n```\n<!-- split: c.md -->\nx\n",
+			map[string]string{"": "```md\n<!-- split: b.md -->\n```\n", "c.md": "x\n"},
+		},
+
+		// Malformed directives are not directives and stay in the text.
+		{"no name", "<!-- split: -->\nx\n", map[string]string{"": "<!-- split: -->\nx\n"}},
+		{"two names", "<!-- split: a.md b.md -->\nx\n", map[string]string{"": "<!-- split: a.md b.md -->\nx\n"}},
+		{"unclosed", "<!-- split: b.md\nx\n", map[string]string{"": "<!-- split: b.md\nx\n"}},
+		{"inline", "see <!-- split: b.md --> here\n", map[string]string{"": "see <!-- split: b.md --> here\n"}},
+		{"other comment", "<!-- splits: b.md -->\n", map[string]string{"": "<!-- splits: b.md -->\n"}},
+	}
+	for _, tt := range tests {
+		parts := SplitDirectives([]byte(tt.content))
+		got := make(map[string]string, len(parts))
+		for name, content := range parts {
+			got[name] = string(content)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: SplitDirectives = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
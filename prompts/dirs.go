@@ -0,0 +1,82 @@
+package prompts
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// DirectoryConflictError is returned by Merge and Walk when two entries of
+// dirs name the same directory or, with StrictDirs, one lies inside the
+// other, so that files would be found twice.
+type DirectoryConflictError struct {
+	// Dir and Other are the conflicting entries as given, Dir being the
+	// later one.
+	Dir, Other string
+
+	// Nested is set when Dir and Other are not the same directory but one
+	// contains the other.
+	Nested bool
+}
+
+func (e *DirectoryConflictError) Error() string {
+	if e.Nested {
+		return fmt.Sprintf("prompts: directories %s and %s overlap", e.Other, e.Dir)
+	}
+	return fmt.Sprintf("prompts: directory %s is listed twice, also as %s", e.Dir, e.Other)
+}
+
+// checkDirs reports entries of dirs that resolve, after following symbolic
+// links, to the same directory as an earlier one, and logs or, with
+// StrictDirs, reports entries nested in one another. Entries that cannot be
+// resolved are left for the walk to report.
+func checkDirs(dirs []string, opts MergeOptions) error {
+	var seen, canon []string
+	for _, dir := range dirs {
+		if isRemote(dir) || dir == StdinDir {
+			continue
+		}
+		real, err := canonicalDir(dir, opts)
+		if err != nil {
+			continue
+		}
+		for i, other := range canon {
+			switch {
+			case real == other:
+				return &DirectoryConflictError{Dir: dir, Other: seen[i]}
+			case within(real, other) || within(other, real):
+				err := &DirectoryConflictError{Dir: dir, Other: seen[i], Nested: true}
+				if opts.StrictDirs {
+					return err
+				}
+				if opts.Logger != nil {
+					opts.Logger.Warn("overlapping directories; files in both are merged once", "dir", dir, "other", seen[i])
+				}
+			}
+		}
+		seen = append(seen, dir)
+		canon = append(canon, real)
+	}
+	return nil
+}
+
+// canonicalDir returns the absolute path of dir with symbolic links
+// resolved, or for sources other than the file system its clean absolute
+// name.
+func canonicalDir(dir string, opts MergeOptions) (string, error) {
+	if opts.Source == nil {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return "", err
+		}
+		dir = real
+	}
+	return filepath.Abs(dir)
+}
+
+// within reports whether path lies below dir. Both must be clean and
+// absolute.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
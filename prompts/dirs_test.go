@@ -0,0 +1,95 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDirsDuplicate(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/a.md": "# A\n", "q/b.md": "# B\n"})
+	dirsList := [][]string{
+		{"p", "p"},
+		{"p", "q", "./p/"},
+	}
+	if err := os.Symlink("p", "link"); err == nil {
+		dirsList = append(dirsList, []string{"p", "link"})
+	} else {
+		t.Logf("no symlink test: %v", err)
+	}
+	for _, dirs := range dirsList {
+		for name, find := range map[string]func() error{
+			"Merge": func() error { _, err := Merge(dirs, DefaultMergeOptions()); return err },
+			"Walk":  func() error { _, err := Walk(dirs, WalkOptions{}); return err },
+		} {
+			err := find()
+			var conflict *DirectoryConflictError
+			if !errors.As(err, &conflict) {
+				t.Errorf("%s(%q): err = %v, want a DirectoryConflictError", name, dirs, err)
+				continue
+			}
+			if want := (DirectoryConflictError{Dir: dirs[len(dirs)-1], Other: "p"}); *conflict != want {
+				t.Errorf("%s(%q): conflict = %+v, want %+v", name, dirs, *conflict, want)
+			}
+		}
+	}
+}
+
+func TestDirsNested(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/a.md": "# A\n", "p/sub/b.md": "# B\n"})
+	var logs bytes.Buffer
+	opts := DefaultMergeOptions()
+	opts.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+
+	// Without StrictDirs the overlap is a warning and each file is merged
+	// once, under the first entry that finds it.
+	for _, dirs := range [][]string{{"p", "p/sub"}, {"p/sub", "p"}} {
+		logs.Reset()
+		res, err := Merge(dirs, opts)
+		if err != nil {
+			t.Fatalf("%q: %v", dirs, err)
+		}
+		if n := len(res.Files); n != 2 {
+			t.Errorf("%q: merged %q, want each file once", dirs, res.Files)
+		}
+		if !strings.Contains(logs.String(), "overlapping directories") {
+			t.Errorf("%q: no warning logged:\n%s", dirs, logs.String())
+		}
+	}
+
+	opts.StrictDirs = true
+	_, err := Merge([]string{"p", "p/sub"}, opts)
+	var conflict *DirectoryConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("StrictDirs: err = %v, want a DirectoryConflictError", err)
+	}
+	if want := (DirectoryConflictError{Dir: "p/sub", Other: "p", Nested: true}); *conflict != want {
+		t.Errorf("StrictDirs: conflict = %+v, want %+v", *conflict, want)
+	}
+
+	// Siblings whose names share a prefix do not overlap.
+	writeFiles(t, map[string]string{"p2/c.md": "# C\n"})
+	res, err := Merge([]string{"p", "p2"}, opts)
+	if err != nil {
+		t.Fatalf("p and p2: %v", err)
+	}
+	if len(res.Files) != 3 {
+		t.Errorf("p and p2: merged %q", res.Files)
+	}
+}
+
+func TestDirectoryConflictErrorMessage(t *testing.T) {
+	dup := &DirectoryConflictError{Dir: "./p", Other: "p"}
+	if got, want := dup.Error(), "prompts: directory ./p is listed twice, also as p"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	nested := &DirectoryConflictError{Dir: "p/sub", Other: "p", Nested: true}
+	if got, want := nested.Error(), "prompts: directories p and p/sub overlap"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// StdinDir is the dirs entry that stands for MergeOptions.Files, which the
+// command line tool reads from standard input.
+const StdinDir = "-"
+
+// ErrListedFile is returned by Merge for an entry of MergeOptions.Files
+// that is not a file with one of the merged extensions.
+var ErrListedFile = errors.New("prompts: listed path is not a prompt file")
+
+// ReadFileList reads file paths from r, one per line as printed by find or
+// git ls-files, or NUL-terminated as printed by find -print0 or git
+// ls-files -z. Input containing a NUL byte is read as NUL-terminated and
+// the paths are kept exactly; otherwise surrounding whitespace is trimmed.
+// Blank entries are ignored.
+func ReadFileList(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: read file list: %w", err)
+	}
+	var paths []string
+	if bytes.IndexByte(data, 0) >= 0 {
+		for _, path := range strings.Split(string(data), "\x00") {
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+		return paths, nil
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line := strings.TrimSpace(line); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
+
+// listedSources returns the sources for opts.Files, the StdinDir entry of
+// dirs at rootIndex, in the order listed. Files that Include or Exclude
+// reject or that were already found are skipped.
+func listedSources(rootIndex int, exts []string, found map[string]bool, opts MergeOptions) ([]source, error) {
+	var files []source
+	for _, path := range opts.Files {
+		path = filepath.Clean(path)
+		info, err := sourceOf(opts).Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("prompts: stat %s: %w", path, err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("%w: %s is a directory", ErrListedFile, path)
+		}
+		if !hasExt(path, exts) {
+			return nil, fmt.Errorf("%w: %s does not have one of the extensions %s", ErrListedFile, path, strings.Join(exts, ", "))
+		}
+		if !included(path, opts) || found[path] {
+			continue
+		}
+		ok, err := checkSize(path, info, opts)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found[path] = true
+			files = append(files, source{
+				Path:      path,
+				File:      path,
+				root:      filepath.Dir(path),
+				rootIndex: rootIndex,
+				info:      info,
+				fsys:      opts.Source,
+			})
+		}
+	}
+	return files, nil
+}
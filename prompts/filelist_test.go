@@ -0,0 +1,87 @@
+package prompts
+
+import (
+	"errors"
+	"io/fs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFileList(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"newlines", "p/b.md\np/a.md\n", []string{"p/b.md", "p/a.md"}},
+		{"blank lines and spaces", "\n  p/b.md \r\n\n\tp/a.md", []string{"p/b.md", "p/a.md"}},
+		{"NUL-terminated", "p/b.md\x00p/a.md\x00", []string{"p/b.md", "p/a.md"}},
+		{"NUL keeps names exactly", " p/with space.md\x00p/new\nline.md\x00\x00", []string{" p/with space.md", "p/new\nline.md"}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		got, err := ReadFileList(strings.NewReader(tt.input))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ReadFileList = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeFileList(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"p/a.md":        "# A\n",
+		"p/b.md":        "# B\n",
+		"p/with spa.md": "# Space\n",
+		"p/notes.txt":   "notes\n",
+	})
+	for _, input := range []string{
+		"p/b.md\np/with spa.md\np/a.md\n",
+		"p/b.md\x00p/with spa.md\x00p/a.md\x00",
+	} {
+		files, err := ReadFileList(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := DefaultMergeOptions()
+		opts.Files = files
+		opts.Sort = false
+		res, err := Merge([]string{StdinDir}, opts)
+		if err != nil {
+			t.Fatalf("%q: %v", input, err)
+		}
+		// Listed files keep the input order.
+		if want := "# B\n\n# Space\n\n# A\n\n"; string(res.Content) != want {
+			t.Errorf("%q: merged %q, want %q", input, res.Content, want)
+		}
+
+		opts.Sort = true
+		if res, err = Merge([]string{StdinDir}, opts); err != nil {
+			t.Fatal(err)
+		}
+		if want := "# A\n\n# B\n\n# Space\n\n"; string(res.Content) != want {
+			t.Errorf("%q sorted: merged %q, want %q", input, res.Content, want)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		err   error
+	}{
+		{"missing path", []string{"p/a.md", "p/gone.md"}, fs.ErrNotExist},
+		{"directory", []string{"p"}, ErrListedFile},
+		{"other extension", []string{"p/notes.txt"}, ErrListedFile},
+	}
+	for _, tt := range tests {
+		opts := DefaultMergeOptions()
+		opts.Files = tt.files
+		if _, err := Merge([]string{StdinDir}, opts); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
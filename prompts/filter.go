@@ -0,0 +1,62 @@
+package prompts
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// matchAny reports whether path matches one of patterns. Each pattern is
+// tried, with filepath.Match semantics, against both the full path and its
+// base name, so "*.draft.md" matches "general/intro.draft.md".
+func matchAny(patterns []string, path string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, path); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePatterns reports the first malformed pattern in patterns.
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("prompts: pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// included reports whether path passes the include and exclude filters of
+// opts. Exclusion wins when a path matches both.
+func included(path string, opts MergeOptions) bool {
+	if matchAny(opts.Exclude, path) || skipped(path, opts) {
+		return false
+	}
+	return len(opts.Include) == 0 || matchAny(opts.Include, path)
+}
+
+// skipped reports whether path names one of the files opts.Skip lists or
+// the cache file.
+func skipped(path string, opts MergeOptions) bool {
+	if len(opts.Skip) == 0 && opts.CacheFile == "" {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for _, p := range append(opts.Skip, opts.CacheFile) {
+		if p == "" {
+			continue
+		}
+		if skip, err := filepath.Abs(p); err == nil && skip == abs {
+			return true
+		}
+	}
+	return false
+}
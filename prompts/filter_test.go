@@ -0,0 +1,52 @@
+package prompts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeIncludeExclude(t *testing.T) {
+	tests := []struct {
+		name             string
+		include, exclude []string
+		want             []string
+	}{
+		{"all", nil, nil, []string{"p/a.md", "p/b.draft.md", "p/sub/c.md"}},
+		{"include", []string{"*.md"}, nil, []string{"p/a.md", "p/b.draft.md", "p/sub/c.md"}},
+		{"include path", []string{"p/sub/*"}, nil, []string{"p/sub/c.md"}},
+		{"exclude", nil, []string{"*.draft.md"}, []string{"p/a.md", "p/sub/c.md"}},
+		{"both", []string{"b.*"}, []string{"*.draft.md"}, nil},
+		{"same pattern", []string{"a.md"}, []string{"a.md"}, nil},
+		{"exclude wins", []string{"*.md"}, []string{"p/sub/c.md"}, []string{"p/a.md", "p/b.draft.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t)
+			writeFiles(t, map[string]string{
+				"p/a.md":       "# A\n",
+				"p/b.draft.md": "# B\n",
+				"p/sub/c.md":   "# C\n",
+			})
+			opts := DefaultMergeOptions()
+			opts.Include = tt.include
+			opts.Exclude = tt.exclude
+			res, err := Merge([]string{"p"}, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(res.Files, tt.want) {
+				t.Errorf("files = %q, want %q", res.Files, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeBadPattern(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/a.md": "# A\n"})
+	opts := DefaultMergeOptions()
+	opts.Exclude = []string{"[unclosed"}
+	if _, err := Merge([]string{"p"}, opts); err == nil {
+		t.Error("merge with a malformed pattern succeeded")
+	}
+}
@@ -0,0 +1,132 @@
+package prompts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Format selects how the merged document is encoded.
+type Format string
+
+const (
+	// FormatMarkdown writes the merged Markdown unchanged. It is the
+	// default when MergeOptions.Format is empty.
+	FormatMarkdown Format = "md"
+
+	// FormatText strips Markdown syntax and writes plain text.
+	FormatText Format = "txt"
+
+	// FormatJSON writes a JSON object holding the plain text prompt, the
+	// source paths and MergeOptions.GeneratedAt, if set.
+	FormatJSON Format = "json"
+)
+
+// ErrUnknownFormat is returned when a format name is not one of the Format
+// constants.
+var ErrUnknownFormat = errors.New("prompts: unknown format")
+
+// ParseFormat converts a format name such as "json" to a Format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatMarkdown, FormatText, FormatJSON:
+		return f, nil
+	case "":
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, s)
+}
+
+// jsonOutput is the document written in FormatJSON.
+type jsonOutput struct {
+	Prompt      string   `json:"prompt"`
+	Sources     []string `json:"sources"`
+	GeneratedAt string   `json:"generated_at,omitempty"`
+}
+
+// encode converts merged Markdown to format. sources are the merged paths,
+// and generatedAt the time recorded in JSON unless it is zero.
+func encode(markdown []byte, sources []string, generatedAt time.Time, format Format) ([]byte, error) {
+	switch format {
+	case FormatMarkdown, "":
+		return markdown, nil
+	case FormatText:
+		return []byte(stripMarkdown(string(markdown))), nil
+	case FormatJSON:
+		out := jsonOutput{
+			Prompt:  stripMarkdown(string(markdown)),
+			Sources: make([]string, len(sources)),
+		}
+		for i, s := range sources {
+			out.Sources[i] = filepath.ToSlash(s)
+		}
+		if !generatedAt.IsZero() {
+			out.GeneratedAt = generatedAt.UTC().Format(time.RFC3339)
+		}
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("prompts: encode json: %w", err)
+		}
+		return append(data, '\n'), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+var (
+	mdComment  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	mdAnchor   = regexp.MustCompile(`<a id="[^"]*"></a>`)
+	mdImage    = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	mdLink     = regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`)
+	mdCode     = regexp.MustCompile("`([^`]+)`")
+	mdStrong   = regexp.MustCompile(`(\*\*|__)(\S(?:.*?\S)?)(\*\*|__)`)
+	mdEmphasis = regexp.MustCompile(`(^|[^\w*])[*_](\S(?:[^*_]*?\S)?)[*_]`)
+	mdHeading  = regexp.MustCompile(`^#{1,6}\s+`)
+	mdQuote    = regexp.MustCompile(`^\s*>\s?`)
+	mdBullet   = regexp.MustCompile(`^(\s*)[*+-]\s+`)
+	mdRule     = regexp.MustCompile(`^\s*([-*_])(\s*[-*_]){2,}\s*$`)
+	mdBlankRun = regexp.MustCompile(`\n{3,}`)
+)
+
+// stripMarkdown renders Markdown as plain text. It handles the constructs
+// prompt files use in practice (headings, emphasis, links, lists, quotes,
+// rules and fenced code) rather than the full CommonMark grammar. Code
+// inside fences is kept verbatim.
+func stripMarkdown(markdown string) string {
+	markdown = mdComment.ReplaceAllString(markdown, "")
+	markdown = mdAnchor.ReplaceAllString(markdown, "")
+
+	var b strings.Builder
+	inFence := false
+	for _, line := range strings.Split(markdown, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			b.WriteString(line)
+			b.WriteByte('\n')
+			continue
+		}
+		if mdRule.MatchString(line) {
+			b.WriteByte('\n')
+			continue
+		}
+		line = mdHeading.ReplaceAllString(line, "")
+		line = mdQuote.ReplaceAllString(line, "")
+		line = mdBullet.ReplaceAllString(line, "${1}- ")
+		line = mdImage.ReplaceAllString(line, "$1")
+		line = mdLink.ReplaceAllString(line, "$1")
+		line = mdCode.ReplaceAllString(line, "$1")
+		line = mdStrong.ReplaceAllString(line, "$2")
+		line = mdEmphasis.ReplaceAllString(line, "$1$2")
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+
+	text := mdBlankRun.ReplaceAllString(b.String(), "\n\n")
+	return strings.TrimSpace(text) + "\n"
+}
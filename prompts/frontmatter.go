@@ -0,0 +1,172 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidFrontMatter is returned when a file opens with a front matter
+// block that cannot be parsed.
+var ErrInvalidFrontMatter = errors.New("prompts: invalid front matter")
+
+// FrontMatter is the metadata block a prompt file may open with, fenced by
+// "---" lines. The block is YAML; keys other than those below are ignored,
+// whatever their value.
+type FrontMatter struct {
+	Title    string   `json:"title,omitempty"`
+	Priority int      `json:"priority,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Disabled bool     `json:"disabled,omitempty"`
+
+	// LintIgnore names the validation rules, such as
+	// "max-tokens-per-file", whose diagnostics are dropped for the file.
+	LintIgnore []string `json:"lint_ignore,omitempty"`
+}
+
+const frontMatterFence = "---"
+
+// ParseFrontMatter splits content into its front matter and the remaining
+// body. Content that does not start with a "---" line, or whose opening
+// fence is never closed, has no front matter and is returned unchanged as
+// the body.
+func ParseFrontMatter(content []byte) (FrontMatter, []byte, error) {
+	var fm FrontMatter
+
+	first, rest, ok := cutLine(content)
+	if !ok || strings.TrimRight(string(first), " \t") != frontMatterFence {
+		return fm, content, nil
+	}
+
+	var lines []string
+	for len(rest) > 0 {
+		var line []byte
+		line, rest, _ = cutLine(rest)
+		if strings.TrimRight(string(line), " \t") == frontMatterFence {
+			if err := fm.parse(lines); err != nil {
+				return FrontMatter{}, content, err
+			}
+			return fm, rest, nil
+		}
+		lines = append(lines, string(line))
+	}
+	return FrontMatter{}, content, nil
+}
+
+// FileFrontMatter is ParseFrontMatter for the content of the file at path.
+// Data files, which Merge embeds as code blocks, have no front matter:
+// their "---" lines are YAML document markers, so content is returned
+// unchanged as the body.
+func FileFrontMatter(path string, content []byte) (FrontMatter, []byte, error) {
+	if codeLanguage(path) != "" {
+		return FrontMatter{}, content, nil
+	}
+	return ParseFrontMatter(content)
+}
+
+// cutLine returns the first line of b without its line ending, and the
+// bytes after it. ok is false when b is empty.
+func cutLine(b []byte) (line, rest []byte, ok bool) {
+	if len(b) == 0 {
+		return nil, nil, false
+	}
+	line, rest, found := bytes.Cut(b, []byte("\n"))
+	if !found {
+		rest = nil
+	}
+	return bytes.TrimSuffix(line, []byte("\r")), rest, true
+}
+
+func (fm *FrontMatter) parse(lines []string) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &doc); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFrontMatter, strings.TrimPrefix(err.Error(), "yaml: "))
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("%w: line %d: expected \"key: value\" pairs", ErrInvalidFrontMatter, root.Line+1)
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if err := fm.set(strings.ToLower(key.Value), value); err != nil {
+			// Lines are counted from the opening fence.
+			return fmt.Errorf("%w: line %d: %v", ErrInvalidFrontMatter, value.Line+1, err)
+		}
+	}
+	return nil
+}
+
+func (fm *FrontMatter) set(key string, value *yaml.Node) error {
+	switch key {
+	case "title":
+		return decodeScalar(key, value, &fm.Title)
+	case "priority":
+		if err := decodeScalar(key, value, &fm.Priority); err != nil {
+			return fmt.Errorf("priority %q is not an integer", value.Value)
+		}
+	case "tags":
+		return decodeList(key, value, &fm.Tags)
+	case "disabled":
+		var s string
+		if err := decodeScalar(key, value, &s); err != nil {
+			return err
+		}
+		b, err := parseBool(s)
+		if err != nil {
+			return err
+		}
+		fm.Disabled = b
+	case "lint_ignore":
+		return decodeList(key, value, &fm.LintIgnore)
+	}
+	return nil
+}
+
+// decodeScalar decodes value, which must be a scalar, into v.
+func decodeScalar(key string, value *yaml.Node, v any) error {
+	if value.Kind != yaml.ScalarNode {
+		return fmt.Errorf("%s takes a single value", key)
+	}
+	return value.Decode(v)
+}
+
+// decodeList accepts either a sequence of scalars or a single scalar.
+func decodeList(key string, value *yaml.Node, list *[]string) error {
+	var items []string
+	switch value.Kind {
+	case yaml.ScalarNode:
+		if value.Tag != "!!null" {
+			items = []string{value.Value}
+		}
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			if item.Kind != yaml.ScalarNode {
+				return fmt.Errorf("%s takes a list of values", key)
+			}
+			if item.Value != "" {
+				items = append(items, item.Value)
+			}
+		}
+	default:
+		return fmt.Errorf("%s takes a list of values", key)
+	}
+	*list = items
+	return nil
+}
+
+// parseBool also accepts the YAML 1.1 spellings yes, no, on and off.
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on":
+		return true, nil
+	case "false", "no", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not a boolean", value)
+}
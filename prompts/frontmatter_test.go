@@ -0,0 +1,134 @@
+package prompts
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    FrontMatter
+		body    string
+	}{
+		{
+			name:    "missing",
+			content: "# Title\n\nBody.\n",
+			body:    "# Title\n\nBody.\n",
+		},
+		{
+			name:    "not leading",
+			content: "# Title\n\n---\ntitle: Ignored\n---\n",
+			body:    "# Title\n\n---\ntitle: Ignored\n---\n",
+		},
+		{
+			name:    "unclosed",
+			content: "---\ntitle: Open\n\nBody.\n",
+			body:    "---\ntitle: Open\n\nBody.\n",
+		},
+		{
+			name:    "empty",
+			content: "---\n---\nBody.\n",
+			body:    "Body.\n",
+		},
+		{
+			name:    "flat",
+			content: "---\ntitle: \"Go style\"\npriority: 3\ntags: [go, style]\ndisabled: yes\n---\nBody.\n",
+			want:    FrontMatter{Title: "Go style", Priority: 3, Tags: []string{"go", "style"}, Disabled: true},
+			body:    "Body.\n",
+		},
+		{
+			name:    "block lists",
+			content: "---\ntags:\n  - go\n  - style\nlint_ignore:\n- min-content\n---\nBody.\n",
+			want:    FrontMatter{Tags: []string{"go", "style"}, LintIgnore: []string{"min-content"}},
+			body:    "Body.\n",
+		},
+		{
+			name:    "scalar tag",
+			content: "---\ntags: go\n---\nBody.\n",
+			want:    FrontMatter{Tags: []string{"go"}},
+			body:    "Body.\n",
+		},
+		{
+			name:    "nested and folded unknown keys",
+			content: "---\ntitle: Nested\nmodel:\n  name: gpt\n  temperature: 0.2\ndescription: >\n  A folded\n  description.\n---\nBody.\n",
+			want:    FrontMatter{Title: "Nested"},
+			body:    "Body.\n",
+		},
+		{
+			name:    "folded title",
+			content: "---\ntitle: >\n  Two\n  lines\n---\nBody.\n",
+			want:    FrontMatter{Title: "Two lines"},
+			body:    "Body.\n",
+		},
+		{
+			name:    "crlf",
+			content: "---\r\nTitle: Windows\r\n---\r\nBody.\r\n",
+			want:    FrontMatter{Title: "Windows"},
+			body:    "Body.\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, err := ParseFrontMatter([]byte(tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(fm, tt.want) {
+				t.Errorf("front matter = %+v, want %+v", fm, tt.want)
+			}
+			if string(body) != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestParseFrontMatterMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		msg     string
+	}{
+		{"bad yaml", "---\ntitle: [unclosed\n---\n", ""},
+		{"not a mapping", "---\n- a\n- b\n---\n", "line 2"},
+		{"priority", "---\ntitle: T\npriority: high\n---\n", "line 3: priority \"high\" is not an integer"},
+		{"disabled", "---\ndisabled: maybe\n---\n", "\"maybe\" is not a boolean"},
+		{"list title", "---\ntitle: [a, b]\n---\n", "title takes a single value"},
+		{"nested tags", "---\ntags:\n  go: true\n---\n", "tags takes a list of values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := []byte(tt.content)
+			_, body, err := ParseFrontMatter(content)
+			if !errors.Is(err, ErrInvalidFrontMatter) {
+				t.Fatalf("err = %v, want ErrInvalidFrontMatter", err)
+			}
+			if !strings.Contains(err.Error(), tt.msg) {
+				t.Errorf("err = %q, want it to contain %q", err, tt.msg)
+			}
+			if string(body) != tt.content {
+				t.Errorf("body = %q, want the content unchanged", body)
+			}
+		})
+	}
+}
+
+func TestMergeNestedFrontMatter(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"p/a.md": "---\ntitle: A\nmodel:\n  name: gpt\ndescription: >\n  Folded.\n---\n# A\n",
+	})
+	opts := DefaultMergeOptions()
+	opts.StripFrontMatter = true
+	res, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(res.Content)); got != "# A" {
+		t.Errorf("content = %q, want %q", got, "# A")
+	}
+}
@@ -0,0 +1,60 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"text/template"
+	"time"
+)
+
+// DefaultHeaderTemplate is the header written before each file by the
+// go-prompts -headers flag.
+const DefaultHeaderTemplate = "<!-- source: {{.Path}} -->"
+
+// HeaderData is the value MergeOptions.HeaderTemplate is executed with.
+type HeaderData struct {
+	// Path is the source file path as discovered, with "/" separators on
+	// every system so that the output does not depend on it.
+	Path string
+
+	// Index is the position of the file in the merge, starting at 1.
+	Index int
+
+	// Title is the front matter title, or "" when the file has none.
+	Title string
+
+	// ModTime is the file's modification time.
+	ModTime time.Time
+}
+
+// parseHeaderTemplate parses text, returning nil when it is empty.
+func parseHeaderTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("header").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: header template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderHeader executes tmpl for doc and terminates the result with a
+// newline.
+func renderHeader(tmpl *template.Template, doc *document, index int) ([]byte, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, HeaderData{
+		Path:    filepath.ToSlash(doc.Path),
+		Index:   index,
+		Title:   doc.FrontMatter.Title,
+		ModTime: doc.ModTime,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("prompts: header for %s: %w", doc.Path, err)
+	}
+	if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,64 @@
+package prompts
+
+import "testing"
+
+func TestMergeHeaderTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+	}{
+		{
+			name:     "default",
+			template: DefaultHeaderTemplate,
+			want: "<!-- source: p/a.md -->\n---\ntitle: Alpha\n---\n# A\n\n" +
+				"<!-- source: p/sub/b.md -->\n# B\n\n",
+		},
+		{
+			name:     "custom",
+			template: "## {{.Index}}. {{if .Title}}{{.Title}}{{else}}{{.Path}}{{end}} ({{.ModTime.Format \"2006-01-02\"}})\n",
+			want: "## 1. Alpha (2025-01-02)\n---\ntitle: Alpha\n---\n# A\n\n" +
+				"## 2. p/sub/b.md (2025-01-02)\n# B\n\n",
+		},
+		{
+			name:     "empty",
+			template: "",
+			want:     "---\ntitle: Alpha\n---\n# A\n\n# B\n\n",
+		},
+		{
+			name:     "empty output",
+			template: "{{if .Title}}# {{.Title}}{{end}}",
+			want:     "# Alpha\n---\ntitle: Alpha\n---\n# A\n\n\n# B\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t)
+			writeFiles(t, map[string]string{
+				"p/a.md":     "---\ntitle: Alpha\n---\n# A\n",
+				"p/sub/b.md": "# B\n",
+			})
+			opts := DefaultMergeOptions()
+			opts.HeaderTemplate = tt.template
+			res, err := Merge([]string{"p"}, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(res.Content); got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeHeaderTemplateErrors(t *testing.T) {
+	for _, template := range []string{"{{.Path", "{{.Missing}}"} {
+		inTempDir(t)
+		writeFiles(t, map[string]string{"p/a.md": "# A\n"})
+		opts := DefaultMergeOptions()
+		opts.HeaderTemplate = template
+		if _, err := Merge([]string{"p"}, opts); err == nil {
+			t.Errorf("template %q: merge succeeded", template)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package prompts
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixtureTime is the modification time given to fixture files, so that
+// they look the same on every run.
+var fixtureTime = time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// writeFiles creates files, mapping slash-separated paths relative to the
+// current directory to their content, with fixtureTime as their
+// modification time.
+func writeFiles(t testing.TB, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.FromSlash(name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, fixtureTime, fixtureTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// inTempDir changes to a new temporary directory for the rest of the test.
+func inTempDir(t *testing.T) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+}
+
+// gitRepo makes the current directory a Git repository with one commit of
+// files, leaving them in the working tree, and returns the commit ID. The
+// test is skipped if git is not installed.
+func gitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	writeFiles(t, files)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "fixture"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	out, err := exec.Command("git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,81 @@
+package prompts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultLockFile is where the command line tool records the hash of the
+// output it generated, for -hash-guard.
+const DefaultLockFile = ".prompt-lock"
+
+// ErrOutputModified is returned by CheckLock when the output was changed
+// after it was generated.
+var ErrOutputModified = errors.New("prompts: output modified since it was generated")
+
+// Lock records the output of the last merge, so that later runs can tell
+// whether it was edited by hand.
+type Lock struct {
+	Output    string            `json:"output"`
+	SHA256    string            `json:"sha256"`
+	Timestamp string            `json:"timestamp"`
+	Options   map[string]string `json:"options"`
+}
+
+// WriteLock records r as the content of outputPath in the lock file at
+// path. options should describe the settings the merge was run with,
+// typically the command-line flags.
+func (r *Result) WriteLock(path, outputPath string, options map[string]string) error {
+	lock := Lock{
+		Output:    filepath.Clean(outputPath),
+		SHA256:    r.contentSHA256(),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Options:   options,
+	}
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("prompts: encode lock: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o666); err != nil {
+		return fmt.Errorf("prompts: write lock %s: %w", path, err)
+	}
+	return nil
+}
+
+// CheckLock returns an error wrapping ErrOutputModified if outputPath no
+// longer has the content recorded for it in the lock file at path. It
+// returns nil when there is no lock file, when the lock is for another
+// output, or when the output does not exist.
+func CheckLock(path, outputPath string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("prompts: read lock %s: %w", path, err)
+	}
+	var lock Lock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return fmt.Errorf("prompts: parse lock %s: %w", path, err)
+	}
+	if lock.Output != filepath.Clean(outputPath) {
+		return nil
+	}
+
+	content, err := os.ReadFile(outputPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("prompts: read %s: %w", outputPath, err)
+	}
+	if sha256Hex(content) != lock.SHA256 {
+		return fmt.Errorf("%w: %s no longer matches the output generated at %s (see %s)", ErrOutputModified, outputPath, lock.Timestamp, path)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package prompts
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/a.md": "# A\n"})
+	if err := CheckLock(DefaultLockFile, "prompt.md"); err != nil {
+		t.Fatalf("no lock: %v", err)
+	}
+
+	res, err := Merge([]string{"p"}, DefaultMergeOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := res.WriteFile("prompt.md"); err != nil {
+		t.Fatal(err)
+	}
+	if err := res.WriteLock(DefaultLockFile, "prompt.md", map[string]string{"dirs": "p"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckLock(DefaultLockFile, "prompt.md"); err != nil {
+		t.Errorf("unchanged output: %v", err)
+	}
+	if err := CheckLock(DefaultLockFile, "./prompt.md"); err != nil {
+		t.Errorf("unchanged output named differently: %v", err)
+	}
+
+	var lock Lock
+	data, err := os.ReadFile(DefaultLockFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		t.Fatal(err)
+	}
+	if lock.Output != "prompt.md" || lock.SHA256 != sha256Hex(res.Content) || lock.Timestamp == "" || lock.Options["dirs"] != "p" {
+		t.Errorf("lock = %+v", lock)
+	}
+
+	// An edit by hand trips the guard.
+	if err := os.WriteFile("prompt.md", []byte("# A, edited\n\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckLock(DefaultLockFile, "prompt.md"); !errors.Is(err, ErrOutputModified) {
+		t.Errorf("edited output: err = %v, want ErrOutputModified", err)
+	}
+	if err := CheckLock(DefaultLockFile, "other.md"); err != nil {
+		t.Errorf("output the lock is not for: %v", err)
+	}
+
+	// Once the output is regenerated and the lock updated, it passes.
+	if err := res.WriteFile("prompt.md"); err != nil {
+		t.Fatal(err)
+	}
+	if err := res.WriteLock(DefaultLockFile, "prompt.md", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckLock(DefaultLockFile, "prompt.md"); err != nil {
+		t.Errorf("after updating the lock: %v", err)
+	}
+
+	if err := os.WriteFile(DefaultLockFile, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckLock(DefaultLockFile, "prompt.md"); err == nil {
+		t.Error("corrupt lock: no error")
+	}
+}
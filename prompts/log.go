@@ -0,0 +1,56 @@
+package prompts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// MergeLog is the audit record WriteLog stores next to a merged file.
+type MergeLog struct {
+	Files        []SourceRecord    `json:"files"`
+	MergedSHA256 string            `json:"merged_sha256"`
+	TotalBytes   int               `json:"total_bytes"`
+	Timestamp    string            `json:"timestamp"`
+	Options      map[string]string `json:"options"`
+	Compression  *CompressStats    `json:"compression,omitempty"`
+}
+
+// LogPath returns the default log path for outputPath: the same path with
+// its extension replaced by ".log.json".
+func LogPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".log.json"
+}
+
+// Log returns the audit record of r. options should describe the settings
+// the merge was run with, typically the command-line flags. The record
+// includes the compressed copy last written by WriteCompressed, if any.
+func (r *Result) Log(options map[string]string) *MergeLog {
+	files := r.Sources
+	if files == nil {
+		files = []SourceRecord{}
+	}
+	return &MergeLog{
+		Files:        files,
+		MergedSHA256: r.contentSHA256(),
+		TotalBytes:   r.contentSize(),
+		Timestamp:    time.Now().UTC().Format(time.RFC3339),
+		Options:      options,
+		Compression:  r.compressed,
+	}
+}
+
+// WriteLog writes the audit record of r as JSON to path.
+func (r *Result) WriteLog(path string, options map[string]string) error {
+	data, err := json.MarshalIndent(r.Log(options), "", "  ")
+	if err != nil {
+		return fmt.Errorf("prompts: encode log: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o666); err != nil {
+		return fmt.Errorf("prompts: write log %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package prompts
+
+import (
+	"bytes"
+	"unicode"
+)
+
+// NormalizeContent returns b with CRLF line endings converted to LF,
+// trailing whitespace removed from every line and exactly one newline at
+// the end. Content that is empty or only whitespace normalizes to nothing.
+func NormalizeContent(b []byte) []byte {
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	lines := bytes.Split(b, []byte("\n"))
+	out := make([]byte, 0, len(b)+1)
+	for _, line := range lines {
+		out = append(out, bytes.TrimRightFunc(line, unicode.IsSpace)...)
+		out = append(out, '\n')
+	}
+	out = bytes.TrimRight(out, "\n")
+	if len(out) == 0 {
+		return out
+	}
+	return append(out, '\n')
+}
@@ -0,0 +1,45 @@
+package prompts
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"unicode"
+)
+
+func FuzzNormalizeContent(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		" \n\t\n",
+		"# Title\r\n\r\nBody.  \r\n",
+		"a\rb\r",
+		"no final newline",
+		"trailing blank lines\n\n\n",
+		"\xff\xfe invalid \xc3\n",
+		"unicode 　\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, in []byte) {
+		out := NormalizeContent(in)
+
+		if again := NormalizeContent(out); !bytes.Equal(again, out) {
+			t.Fatalf("not idempotent: %q -> %q -> %q", in, out, again)
+		}
+		if bytes.Contains(out, []byte("\r\n")) {
+			t.Fatalf("%q -> %q keeps a CRLF", in, out)
+		}
+		if len(out) > 0 && (out[len(out)-1] != '\n' || bytes.HasSuffix(out, []byte("\n\n"))) {
+			t.Fatalf("%q -> %q does not end with exactly one newline", in, out)
+		}
+		for _, line := range bytes.Split(bytes.TrimSuffix(out, []byte("\n")), []byte("\n")) {
+			if trimmed := bytes.TrimRightFunc(line, unicode.IsSpace); len(trimmed) != len(line) {
+				t.Fatalf("%q -> %q keeps trailing whitespace on %q", in, out, line)
+			}
+		}
+		// Only whitespace is ever removed.
+		if got, want := bytes.Fields(out), bytes.Fields(in); !slices.EqualFunc(got, want, bytes.Equal) {
+			t.Fatalf("%q -> %q changes the text: %q, want %q", in, out, got, want)
+		}
+	})
+}
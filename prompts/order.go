@@ -0,0 +1,91 @@
+package prompts
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultOrderFile is the ordering file the command line tool reads when it
+// exists and no other is given.
+const DefaultOrderFile = "order.txt"
+
+// ErrOrderedFileMissing is returned by Merge with StrictOrder when a path
+// in MergeOptions.Order does not exist.
+var ErrOrderedFileMissing = errors.New("prompts: ordered file not found")
+
+// ReadOrderFile reads an ordering file: one path per line, relative to the
+// working directory like the paths Merge reports. A path after "!" names a
+// file to leave out. Blank lines and lines starting with "#" are ignored.
+func ReadOrderFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: read %s: %w", path, err)
+	}
+	var paths []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("prompts: read %s: %w", path, err)
+	}
+	return paths, nil
+}
+
+// applyOrder moves the files named in opts.Order to the front of files, in
+// that order, keeping the rest in their current order, and drops the files
+// named after a "!". A path listed more than once is placed at its first
+// position. Listed paths that were not discovered are ignored, but a
+// warning is logged, or with StrictOrder an error returned, for those that
+// do not exist at all in opts.Source.
+func applyOrder(files []source, opts MergeOptions) ([]source, error) {
+	if len(opts.Order) == 0 {
+		return files, nil
+	}
+	index := make(map[string]int, len(files))
+	for i, f := range files {
+		index[filepath.Clean(f.Path)] = i
+	}
+
+	ordered := make([]source, 0, len(files))
+	placed := make([]bool, len(files))
+	for _, path := range opts.Order {
+		if excluded, ok := strings.CutPrefix(path, "!"); ok {
+			if i, ok := index[filepath.Clean(excluded)]; ok {
+				placed[i] = true
+			}
+			continue
+		}
+		i, ok := index[filepath.Clean(path)]
+		if !ok {
+			if _, err := sourceOf(opts).Stat(path); err != nil {
+				if opts.StrictOrder {
+					return nil, fmt.Errorf("%w: %s", ErrOrderedFileMissing, path)
+				}
+				if opts.Logger != nil {
+					opts.Logger.Warn("ordered file not found", "file", path)
+				}
+			}
+			continue
+		}
+		if !placed[i] {
+			ordered = append(ordered, files[i])
+			placed[i] = true
+		}
+	}
+	for i, f := range files {
+		if !placed[i] {
+			ordered = append(ordered, f)
+		}
+	}
+	return ordered, nil
+}
@@ -0,0 +1,95 @@
+package prompts
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMergeOrderFile(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"p/a.md":     "A\n",
+		"p/b.md":     "B\n",
+		"p/c.md":     "C\n",
+		"p/sub/d.md": "D\n",
+		"order.txt": "# Merged first, in this order.\n" +
+			"p/sub/d.md\n" +
+			"./p/c.md\n" +
+			"\n" +
+			"p/sub/../sub/d.md\n" +
+			"p/c.md\n" +
+			"!p/b.md\n",
+	})
+	order, err := ReadOrderFile("order.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultMergeOptions()
+	opts.Order = order
+	res, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"p/sub/d.md", "p/c.md", "p/a.md"}; !reflect.DeepEqual(res.Files, want) {
+		t.Errorf("files = %q, want %q", res.Files, want)
+	}
+	if got, want := string(res.Content), "D\n\nC\n\nA\n\n"; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+}
+
+func TestMergeOrderMissing(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/a.md": "A\n"})
+	opts := DefaultMergeOptions()
+	opts.Order = []string{"p/gone.md", "p/a.md"}
+	res, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"p/a.md"}; !reflect.DeepEqual(res.Files, want) {
+		t.Errorf("files = %q, want %q", res.Files, want)
+	}
+
+	opts.StrictOrder = true
+	if _, err := Merge([]string{"p"}, opts); !errors.Is(err, ErrOrderedFileMissing) {
+		t.Errorf("err = %v, want ErrOrderedFileMissing", err)
+	}
+}
+
+func TestReadOrderFileMissing(t *testing.T) {
+	inTempDir(t)
+	if _, err := ReadOrderFile("order.txt"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestMergeOrderGitSource(t *testing.T) {
+	inTempDir(t)
+	ref := gitRepo(t, map[string]string{
+		"p/a.md":         "A\n",
+		"p/committed.md": "C\n",
+	})
+	// The working tree has a file the commit does not, and lacks one it
+	// has; only the commit counts.
+	writeFiles(t, map[string]string{"p/worktree.md": "W\n"})
+	if err := os.Remove("p/committed.md"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultMergeOptions()
+	opts.Source = GitSource{Ref: ref}
+	opts.StrictOrder = true
+	opts.Exclude = []string{"committed.md"}
+	opts.Order = []string{"p/committed.md", "p/a.md"}
+	if _, err := Merge([]string{"p"}, opts); err != nil {
+		t.Errorf("ordered file in the commit: %v", err)
+	}
+
+	opts.Order = []string{"p/worktree.md", "p/a.md"}
+	if _, err := Merge([]string{"p"}, opts); !errors.Is(err, ErrOrderedFileMissing) {
+		t.Errorf("ordered file only in the working tree: err = %v, want ErrOrderedFileMissing", err)
+	}
+}
@@ -0,0 +1,35 @@
+package prompts
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// BenchmarkMergeParallelism merges 500 synthetic files reading one at a
+// time and with one reader per CPU, at least four.
+func BenchmarkMergeParallelism(b *testing.B) {
+	b.Chdir(b.TempDir())
+	files := make(map[string]string, 500)
+	for i := range 500 {
+		files[fmt.Sprintf("p/%02d/%03d.md", i/50, i)] = fmt.Sprintf("---\ntitle: File %d\n---\n# File %d\n\n%s\n", i, i, strings.Repeat("Some prompt text. ", 100))
+	}
+	writeFiles(b, files)
+
+	for _, n := range []int{1, max(runtime.NumCPU(), 4)} {
+		b.Run(fmt.Sprintf("parallelism=%d", n), func(b *testing.B) {
+			opts := DefaultMergeOptions()
+			opts.Parallelism = n
+			for b.Loop() {
+				res, err := Merge([]string{"p"}, opts)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(res.Files) != len(files) {
+					b.Fatalf("merged %d files, want %d", len(res.Files), len(files))
+				}
+			}
+		})
+	}
+}
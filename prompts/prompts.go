@@ -0,0 +1,734 @@
+// Package prompts merges prompt fragments spread across several directories
+// into a single document.
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// MergeOptions controls how MergePrompts discovers and joins files.
+type MergeOptions struct {
+	// Extensions lists the file extensions to merge, with or without the
+	// leading dot. An empty list means ".md". Files ending in .json, .yaml
+	// or .yml are embedded whole as fenced code blocks, below a comment
+	// with their file name; they have no front matter, see
+	// FileFrontMatter.
+	Extensions []string
+
+	// Separator is written after the content of every merged file.
+	Separator string
+
+	// Include, when non-empty, restricts the merge to files matching at
+	// least one of its filepath.Match patterns. A pattern matches either
+	// the file's path or its base name.
+	Include []string
+
+	// Exclude skips files matching any of its patterns. It takes
+	// precedence over Include.
+	Exclude []string
+
+	// Skip lists files that are never merged even when found under dirs,
+	// such as the output of a previous run and the files written beside
+	// it. Paths are compared after filepath.Abs. CacheFile is always
+	// skipped.
+	Skip []string
+
+	// Tags, when non-empty, restricts the merge to files whose front
+	// matter lists at least one of them; see FilterByTags.
+	Tags []string
+
+	// RequireTags drops files without any tags when Tags is set. By
+	// default such files are always merged.
+	RequireTags bool
+
+	// Sort orders the discovered files before merging, as selected by
+	// SortMode.
+	Sort bool
+
+	// SortMode is the order applied when Sort is set. The zero value is
+	// SortLexicographic.
+	SortMode SortMode
+
+	// Source is where local files are found and read. Nil means the file
+	// system. Other sources, such as GitSource, are never cached, and
+	// the entries of dirs are compared by name only.
+	Source Source
+
+	// StrictDirs makes Merge fail with a DirectoryConflictError when one
+	// entry of dirs lies inside another. By default this is logged and
+	// the files found under both are merged once. Entries naming the same
+	// directory are always an error.
+	StrictDirs bool
+
+	// Files are the paths merged for a StdinDir entry of dirs, such as a
+	// list read with ReadFileList. Each must exist and have one of the
+	// Extensions; Include and Exclude still apply. They are merged in the
+	// order listed unless Sort is set.
+	Files []string
+
+	// Order lists paths to merge first, in the given order, ahead of the
+	// files sorted by SortMode. A path prefixed with "!" is not merged at
+	// all. Paths are compared after filepath.Clean and must name files
+	// that are found under dirs to take effect.
+	Order []string
+
+	// StrictOrder makes Merge fail with ErrOrderedFileMissing when a path
+	// in Order does not exist, instead of logging a warning.
+	StrictOrder bool
+
+	// StripFrontMatter removes each file's front matter block, fences
+	// included, from the merged output.
+	StripFrontMatter bool
+
+	// Normalize passes each file through NormalizeContent before it is
+	// parsed, so that line endings, trailing whitespace and final newlines
+	// are consistent however the file was edited.
+	Normalize bool
+
+	// SortByPriority orders files by their front matter priority, highest
+	// first. Files with equal priority keep their relative order.
+	SortByPriority bool
+
+	// TOC prepends a table of contents listing every merged file by its
+	// front matter title, or its path when it has none, and places an
+	// anchor before each file for the entries to link to.
+	TOC bool
+
+	// HeaderTemplate, when non-empty, is a text/template executed with a
+	// HeaderData before each file; its output is written on its own line
+	// ahead of the file's content. DefaultHeaderTemplate produces an
+	// HTML comment naming the source path.
+	HeaderTemplate string
+
+	// ExpandEnv substitutes "${NAME}" and "$NAME" placeholders in file
+	// contents with environment variables; see ExpandVars.
+	ExpandEnv bool
+
+	// Vars defines placeholder values that take precedence over the
+	// environment. Setting any enables substitution even without
+	// ExpandEnv.
+	Vars map[string]string
+
+	// StrictVars makes a placeholder naming an undefined variable an error
+	// instead of leaving it in place.
+	StrictVars bool
+
+	// Template executes each file as a text/template with TemplateData,
+	// before variables are expanded. Templates can call the functions
+	// returned by TemplateFuncs and those of the TemplateFuncs option. The
+	// output of templates is never cached.
+	Template bool
+
+	// TemplateFuncs adds functions for Template, replacing the built-in
+	// ones of the same name.
+	TemplateFuncs template.FuncMap
+
+	// Dedup drops paragraphs that already appeared in an earlier file; see
+	// DeduplicateContent.
+	Dedup bool
+
+	// Logger receives diagnostic messages, such as the paragraphs removed
+	// by Dedup. A nil Logger discards them.
+	Logger *slog.Logger
+
+	// Format selects the output encoding. The zero value is
+	// FormatMarkdown.
+	Format Format
+
+	// GeneratedAt, when non-zero, is recorded as the generation time in
+	// FormatJSON output. It is left out by default so that the same input
+	// always encodes the same way.
+	GeneratedAt time.Time
+
+	// MaxChars, when positive, limits the merged Markdown to that many
+	// characters, applied before Format encoding. Truncate selects what
+	// happens to a document that is too long.
+	MaxChars int
+
+	// Truncate is the strategy applied when MaxChars is exceeded. The zero
+	// value is TruncateEnd.
+	Truncate TruncateStrategy
+
+	// FetchTimeout bounds the download of each URL listed in dirs. Zero
+	// means DefaultFetchTimeout. Downloaded bodies are kept in the
+	// system temporary directory and reused if a later fetch fails.
+	FetchTimeout time.Duration
+
+	// StrictRemote makes a URL that cannot be fetched an error. By
+	// default it is logged and skipped.
+	StrictRemote bool
+
+	// Sections, when non-empty, merges only the sections under these
+	// headings, such as "## Examples", instead of whole files. The
+	// sections for each heading are collected from every file in turn and
+	// written below a single copy of the heading, each preceded by its
+	// source header: HeaderTemplate, or DefaultHeaderTemplate if that is
+	// empty. A heading given without "#" matches at any level. TOC is
+	// ignored.
+	Sections []string
+
+	// RequireSection makes a file without any of the Sections an error,
+	// ErrSectionMissing, instead of skipping it.
+	RequireSection bool
+
+	// Parallelism is how many files are read at once. Zero or less means
+	// runtime.NumCPU().
+	Parallelism int
+
+	// MaxFileSize skips files larger than this many bytes, checked before
+	// they are read. Zero disables the limit.
+	MaxFileSize int64
+
+	// StrictSize makes a file over MaxFileSize an error instead of logging
+	// and skipping it.
+	StrictSize bool
+
+	// StreamThreshold is the size in bytes above which outputs are written
+	// to a temporary file renamed into place, so that an interrupted write
+	// leaves the previous output intact. Above it, MergePrompts and
+	// WriteMerge also stream the files into the output one at a time
+	// rather than merging them in memory, when the other options allow.
+	// Zero disables both.
+	StreamThreshold int64
+
+	// CacheFile, when non-empty, is where a cache of per-file hashes and
+	// output offsets is kept between runs, so that files whose
+	// modification time and size are unchanged are not read again. The
+	// cache is only consulted for Markdown output without Dedup and is
+	// discarded whenever any other option changes.
+	CacheFile string
+}
+
+// DefaultMergeOptions returns the options used by the go-prompts command
+// when no flags are given.
+func DefaultMergeOptions() MergeOptions {
+	return MergeOptions{
+		Extensions:      []string{".md", ".yaml", ".yml", ".json"},
+		Separator:       "\n",
+		Sort:            true,
+		Normalize:       true,
+		MaxFileSize:     DefaultMaxFileSize,
+		StreamThreshold: DefaultStreamThreshold,
+	}
+}
+
+// MergePrompts walks dirs, concatenates every file matching opts.Extensions
+// and writes the result to outputPath, replacing any existing file. Files
+// whose front matter sets "disabled: true" are skipped. Entries of dirs
+// that are http:// or https:// URLs are downloaded and merged as files.
+// Merges over opts.StreamThreshold are streamed as by WriteMerge.
+func MergePrompts(dirs []string, outputPath string, opts MergeOptions) error {
+	_, err := WriteMerge(dirs, outputPath, opts)
+	return err
+}
+
+// Result is the outcome of a merge held in memory.
+type Result struct {
+	// Files lists the merged source files in output order.
+	Files []string
+
+	// Content is the merged document, encoded as requested by
+	// MergeOptions.Format. It is nil for a merge streamed by WriteMerge.
+	Content []byte
+
+	// Sources describes every file that was read, in discovery order,
+	// including those left out of Content by front matter, tag filtering
+	// or truncation.
+	Sources []SourceRecord
+
+	// Normalized counts the files that MergeOptions.Normalize changed.
+	Normalized int
+
+	cache           *mergeCache
+	cacheSaved      bool // the cache refers to an output already written
+	compressed      *CompressStats
+	streamThreshold int64
+
+	// For a merge streamed by WriteMerge: the file it was written to and
+	// the SHA-256 and size of what was written.
+	output     string
+	outputSum  string
+	outputSize int64
+}
+
+// SourceRecord describes a file read during a merge.
+type SourceRecord struct {
+	Path     string `json:"path"`
+	SHA256   string `json:"sha256"`
+	Bytes    int64  `json:"bytes"`
+	Title    string `json:"front_matter_title"`
+	Included bool   `json:"included"`
+}
+
+// Merge performs the same work as MergePrompts but returns the merged
+// document instead of writing it.
+func Merge(dirs []string, opts MergeOptions) (*Result, error) {
+	files, err := collectFiles(dirs, opts)
+	if err != nil {
+		return nil, err
+	}
+	return mergeSources(files, opts)
+}
+
+// mergeSources merges files, as collected for opts, in memory.
+func mergeSources(files []source, opts MergeOptions) (*Result, error) {
+	var cache *mergeCache
+	if cacheable(opts) {
+		cache = loadCache(opts)
+	}
+	all, err := loadDocuments(files, opts, cache)
+	if err != nil {
+		return nil, err
+	}
+	docs := selectDocuments(all, opts)
+
+	var merged []byte
+	var spans []docSpan
+	if len(opts.Sections) > 0 {
+		merged, docs, err = concatSections(docs, opts)
+	} else {
+		var buf bytes.Buffer
+		spans, err = writeDocuments(&buf, docs, opts)
+		merged = buf.Bytes()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.MaxChars > 0 && utf8.RuneCount(merged) > opts.MaxChars {
+		if len(opts.Sections) > 0 {
+			// Sections cannot be dropped file by file; cut the text.
+			merged, _, err = truncate(merged, nil, nil, opts)
+		} else {
+			merged, docs, err = truncate(merged, docs, spans, opts)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := &Result{cache: cache, streamThreshold: opts.StreamThreshold}
+	included := make(map[*document]bool, len(docs))
+	for i, doc := range docs {
+		res.Files = append(res.Files, doc.Path)
+		included[doc] = true
+		if doc.cacheIndex >= 0 {
+			entry := &cache.next[doc.cacheIndex]
+			entry.Offset = spans[i].Text
+			entry.Length = len(doc.Text)
+		}
+	}
+	for _, doc := range all {
+		if doc.normalized {
+			res.Normalized++
+		}
+		res.Sources = append(res.Sources, SourceRecord{
+			Path:     doc.Path,
+			SHA256:   doc.SHA256,
+			Bytes:    doc.Size,
+			Title:    doc.FrontMatter.Title,
+			Included: included[doc],
+		})
+	}
+	res.Content, err = encode(merged, res.Files, opts.GeneratedAt, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// WriteFile writes the merged document to path, replacing any existing file,
+// and updates the cache when MergeOptions.CacheFile is set. Documents over
+// MergeOptions.StreamThreshold are written to a temporary file first and
+// renamed into place.
+func (r *Result) WriteFile(path string) error {
+	if r.output != "" {
+		return r.copyOutput(path)
+	}
+	var err error
+	if r.streamThreshold > 0 && int64(len(r.Content)) > r.streamThreshold {
+		_, _, err = writeStreamed(path, bytes.NewReader(r.Content))
+	} else {
+		err = os.WriteFile(path, r.Content, 0o666)
+	}
+	if err != nil {
+		return fmt.Errorf("prompts: write %s: %w", path, err)
+	}
+	return r.saveCache(path)
+}
+
+// saveCache updates the cache, if any, for the document just written to
+// path.
+func (r *Result) saveCache(path string) error {
+	if r.cache == nil {
+		return nil
+	}
+	if err := r.cache.save(path, r.Content); err != nil {
+		return fmt.Errorf("prompts: write cache %s: %w", r.cache.path, err)
+	}
+	r.cacheSaved = true
+	return nil
+}
+
+// source is a file to merge. Path is how the file is named in the output;
+// File is where its content is read from, which differs from Path only for
+// remote files.
+type source struct {
+	Path string
+	File string
+
+	root      string // the dirs entry the file was found under
+	rootIndex int    // the position of root in dirs
+	info      os.FileInfo
+	fsys      Source // nil for the file system
+}
+
+// promptFile returns the PromptFile for s, with its content not yet read.
+func (s source) promptFile() PromptFile {
+	return PromptFile{Path: s.Path, Info: s.info, file: s.File, fsys: s.fsys, content: &lazyContent{}}
+}
+
+// collectFiles returns the paths under dirs whose extension and name are
+// accepted by opts, sorted by opts.SortMode when opts.Sort is set and led by
+// opts.Order. URL entries in dirs are downloaded and included as they are,
+// without filtering.
+func collectFiles(dirs []string, opts MergeOptions) ([]source, error) {
+	if err := validatePatterns(opts.Include); err != nil {
+		return nil, err
+	}
+	if err := validatePatterns(opts.Exclude); err != nil {
+		return nil, err
+	}
+	if err := checkDirs(dirs, opts); err != nil {
+		return nil, err
+	}
+
+	exts := opts.Extensions
+	if len(exts) == 0 {
+		exts = []string{".md"}
+	}
+
+	var files []source
+	found := map[string]bool{} // files already found under an overlapping dirs entry
+	for rootIndex, dir := range dirs {
+		if dir == StdinDir {
+			listed, err := listedSources(rootIndex, exts, found, opts)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, listed...)
+			continue
+		}
+		if isRemote(dir) {
+			file, err := fetchRemote(dir, opts)
+			if err != nil {
+				if opts.StrictRemote {
+					return nil, err
+				}
+				if opts.Logger != nil {
+					opts.Logger.Warn("skipping remote file", "err", err)
+				}
+				continue
+			}
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, fmt.Errorf("prompts: stat %s: %w", dir, err)
+			}
+			if ok, err := checkSize(dir, info, opts); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+			files = append(files, source{Path: dir, File: file, root: dir, rootIndex: rootIndex, info: info})
+			continue
+		}
+
+		err := sourceOf(opts).Walk(dir, func(path string, info os.FileInfo) error {
+			if !hasExt(path, exts) || !included(path, opts) || found[path] {
+				return nil
+			}
+			ok, err := checkSize(path, info, opts)
+			if err != nil {
+				return err
+			}
+			if ok {
+				found[path] = true
+				files = append(files, source{
+					Path:      path,
+					File:      path,
+					root:      dir,
+					rootIndex: rootIndex,
+					info:      info,
+					fsys:      opts.Source,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Sort {
+		sortSources(files, opts.SortMode)
+	}
+	return applyOrder(files, opts)
+}
+
+// document is a prompt file read from disk.
+type document struct {
+	PromptFile
+	ModTime time.Time
+	Size    int64
+	SHA256  string // hash of the file as read
+	Text    []byte // the content to merge, after per-file transformations
+
+	normalized bool // whether Normalize changed the file
+
+	// cacheIndex is the position of the entry recorded for the next run,
+	// whose output offset is filled in once the document has been
+	// written, or -1 when there is no cache.
+	cacheIndex int
+}
+
+// loadDocuments reads files with up to opts.Parallelism files in flight,
+// returning the documents in the order of files. Files found unchanged in
+// cache are not read.
+func loadDocuments(files []source, opts MergeOptions, cache *mergeCache) ([]*document, error) {
+	lookup := varLookup(opts)
+	docs := make([]*document, len(files))
+	err := parallel(len(files), opts.Parallelism, func(i int) error {
+		var err error
+		docs[i], err = loadDocument(files[i], opts, cache, lookup)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		for _, doc := range docs {
+			doc.cacheIndex = len(cache.next)
+			cache.next = append(cache.next, cacheEntry{
+				Path:        doc.Path,
+				ModTime:     doc.ModTime,
+				Size:        doc.Size,
+				SHA256:      doc.SHA256,
+				Offset:      -1,
+				FrontMatter: doc.FrontMatter,
+				Normalized:  doc.normalized,
+			})
+		}
+	}
+	return docs, nil
+}
+
+// loadDocument reads a single file, or takes it from cache when unchanged.
+// It is safe to call concurrently.
+func loadDocument(src source, opts MergeOptions, cache *mergeCache, lookup func(string) (string, bool)) (*document, error) {
+	file := src.Path
+	fsys := src.fsys
+	if fsys == nil {
+		fsys = osSource{}
+	}
+	info, err := fsys.Stat(src.File)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: stat %s: %w", file, err)
+	}
+
+	doc := &document{
+		PromptFile: src.promptFile(),
+		ModTime:    info.ModTime(),
+		Size:       info.Size(),
+		cacheIndex: -1,
+	}
+
+	if e, text, ok := cache.lookup(file, info); ok {
+		doc.SHA256 = e.SHA256
+		doc.FrontMatter = e.FrontMatter
+		doc.Text = text
+		doc.normalized = e.Normalized
+		return doc, nil
+	}
+
+	content, err := doc.Content()
+	if err != nil {
+		return nil, err
+	}
+	doc.SHA256 = sha256Hex(content)
+	if opts.Normalize {
+		normalized := NormalizeContent(content)
+		doc.normalized = !bytes.Equal(normalized, content)
+		content = normalized
+		if doc.normalized && opts.Logger != nil {
+			opts.Logger.Debug("normalized file", "file", file)
+		}
+	}
+	lang := codeLanguage(file)
+	fm, body, err := FileFrontMatter(file, content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	doc.FrontMatter = fm
+	doc.Text = content
+	if opts.StripFrontMatter {
+		doc.Text = body
+	}
+	if opts.Template && !fm.Disabled {
+		data := TemplateData{Path: file, FrontMatter: fm, Vars: opts.Vars}
+		if doc.Text, err = renderTemplate(file, doc.Text, data, opts.TemplateFuncs); err != nil {
+			return nil, err
+		}
+	}
+	if lookup != nil && !fm.Disabled {
+		expanded, err := ExpandVars(string(doc.Text), lookup, opts.StrictVars)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		doc.Text = []byte(expanded)
+	}
+	if lang != "" {
+		doc.Text = append(codeCaption(file), WrapAsCodeBlock(doc.Text, lang)...)
+	}
+	return doc, nil
+}
+
+// selectDocuments drops disabled documents and those rejected by the tag
+// filter, and applies priority ordering.
+func selectDocuments(all []*document, opts MergeOptions) []*document {
+	var docs []*document
+	for _, doc := range all {
+		if selected(doc.FrontMatter, opts) {
+			docs = append(docs, doc)
+		}
+	}
+
+	if opts.SortByPriority {
+		sort.SliceStable(docs, func(i, j int) bool {
+			return docs[i].FrontMatter.Priority > docs[j].FrontMatter.Priority
+		})
+	}
+	return docs
+}
+
+// selected reports whether a file with front matter fm is merged: it is not
+// disabled and passes the tag filter.
+func selected(fm FrontMatter, opts MergeOptions) bool {
+	return !fm.Disabled && matchTags(fm.Tags, opts.Tags, opts.RequireTags)
+}
+
+// docSpan locates a document in the merged output.
+type docSpan struct {
+	Start int // offset of the document's anchor or header, if any
+	Text  int // offset of the document's text
+}
+
+// writeDocuments copies each document to w followed by opts.Separator,
+// preceded by the table of contents when opts.TOC is set. It returns where
+// each document was written.
+func writeDocuments(w io.Writer, docs []*document, opts MergeOptions) ([]docSpan, error) {
+	header, err := parseHeaderTemplate(opts.HeaderTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var toc bytes.Buffer
+	var ids []string
+	if opts.TOC {
+		ids = anchorIDs(docs)
+		writeTOC(&toc, docs, ids)
+	}
+
+	// atLineStart tracks whether the output so far ends with a newline so
+	// that anchors and headers always start on a line of their own.
+	atLineStart := true
+	written := 0
+	write := func(b []byte) error {
+		if len(b) == 0 {
+			return nil
+		}
+		n, err := w.Write(b)
+		written += n
+		if err != nil {
+			return fmt.Errorf("prompts: write: %w", err)
+		}
+		atLineStart = b[len(b)-1] == '\n'
+		return nil
+	}
+	if err := write(toc.Bytes()); err != nil {
+		return nil, err
+	}
+
+	contents := make([][]byte, len(docs))
+	for i, doc := range docs {
+		contents[i] = doc.Text
+	}
+	if opts.Dedup {
+		sections := make([]string, len(docs))
+		names := make([]string, len(docs))
+		for i, doc := range docs {
+			sections[i] = string(contents[i])
+			names[i] = doc.Path
+		}
+		for i, section := range deduplicate(sections, names, opts.Logger) {
+			contents[i] = []byte(section)
+		}
+	}
+
+	spans := make([]docSpan, len(docs))
+	for i, doc := range docs {
+		spans[i].Start = written
+		if opts.TOC {
+			a := anchor(ids[i])
+			if !atLineStart {
+				a = append([]byte("\n"), a...)
+			}
+			if err := write(a); err != nil {
+				return nil, err
+			}
+		}
+		if header != nil {
+			h, err := renderHeader(header, doc, i+1)
+			if err != nil {
+				return nil, err
+			}
+			if !atLineStart {
+				h = append([]byte("\n"), h...)
+			}
+			if err := write(h); err != nil {
+				return nil, err
+			}
+		}
+		spans[i].Text = written
+		if err := write(contents[i]); err != nil {
+			return nil, err
+		}
+		if err := write([]byte(opts.Separator)); err != nil {
+			return nil, err
+		}
+	}
+	return spans, nil
+}
+
+// hasExt reports whether path ends in one of exts.
+func hasExt(path string, exts []string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range exts {
+		if !strings.HasPrefix(e, ".") {
+			e = "." + e
+		}
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
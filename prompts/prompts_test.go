@@ -0,0 +1,107 @@
+package prompts
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// baselineMerge is the merge go-prompts did before it had any options:
+// every .md file under dirs, sorted by path, each followed by a newline.
+func baselineMerge(t *testing.T, dirs []string) []byte {
+	t.Helper()
+	var files []string
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && filepath.Ext(path) == ".md" {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	sort.Strings(files)
+	var out bytes.Buffer
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out.Write(content)
+		out.WriteString("\n")
+	}
+	return out.Bytes()
+}
+
+func TestMergePromptsMatchesBaseline(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		opts  func() MergeOptions
+	}{
+		{
+			// Files that are already normalized merge the same with the
+			// default options.
+			name: "defaults",
+			files: map[string]string{
+				"general/01_structure.md": "# Structure\n\nKeep packages small.\n",
+				"general/02_errors.md":    "---\ntitle: Errors\n---\n# Errors\n\nWrap them.\n",
+				"general/sub/03_deep.md":  "# Deep\n",
+				"libs/01_zerolog.md":      "# zerolog\n",
+				"libs/notes.txt":          "Not a prompt.\n",
+			},
+			opts: DefaultMergeOptions,
+		},
+		{
+			name: "unnormalized",
+			files: map[string]string{
+				"general/a.md": "# A  \r\n\r\nCRLF.\r\n",
+				"general/b.md": "No final newline.",
+				"libs/c.md":    "Blank lines.\n\n\n",
+				"libs/d.json":  "{}\n",
+			},
+			opts: func() MergeOptions {
+				opts := DefaultMergeOptions()
+				opts.Extensions = []string{".md"}
+				opts.Normalize = false
+				return opts
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t)
+			writeFiles(t, tt.files)
+			dirs := []string{"general", "libs"}
+			if err := MergePrompts(dirs, "prompt.md", tt.opts()); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile("prompt.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := baselineMerge(t, dirs); !bytes.Equal(got, want) {
+				t.Errorf("merged\n%q\nwant\n%q", got, want)
+			}
+		})
+	}
+}
+
+func TestMergePromptsMissingDir(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"general/a.md": "# A\n"})
+	err := MergePrompts([]string{"general", "missing"}, "prompt.md", DefaultMergeOptions())
+	if err == nil {
+		t.Fatal("merging a missing directory succeeded")
+	}
+	if _, statErr := os.Stat("prompt.md"); !os.IsNotExist(statErr) {
+		t.Errorf("output written despite the error: %v", statErr)
+	}
+}
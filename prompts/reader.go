@@ -0,0 +1,137 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/template"
+)
+
+// ErrStreamUnsupported is returned by MergeReader for options that need
+// the whole document before any of it can be written.
+var ErrStreamUnsupported = errors.New("prompts: option not supported when streaming")
+
+// MergeReader streams the Markdown document Merge would produce from a
+// list of files, such as one returned by Walk, reading each file only once
+// the previous one has been consumed. At most one file is held in memory
+// at a time.
+type MergeReader struct {
+	files  []PromptFile
+	opts   MergeOptions
+	header *template.Template
+	lookup func(string) (string, bool)
+
+	pending     []byte // output of the current file not yet read
+	next        int    // index in files of the next file to read
+	merged      int    // files written so far
+	atLineStart bool
+	err         error
+
+	// What Merge would report in its Result, filled in as files are read.
+	included   []string
+	sources    []SourceRecord
+	normalized int
+}
+
+// NewMergeReader returns a MergeReader for files, merged in the order
+// given with the per-file options of opts: front matter handling, tags,
+// headers, templates and variables. Files are not sorted and the cache is
+// not used. TOC, Dedup, SortByPriority, MaxChars, Sections and formats
+// other than FormatMarkdown need the whole document and make Read fail
+// with ErrStreamUnsupported.
+func NewMergeReader(files []PromptFile, opts MergeOptions) *MergeReader {
+	r := &MergeReader{files: files, opts: opts, lookup: varLookup(opts), atLineStart: true}
+	if r.err = streamError(opts); r.err == nil {
+		r.header, r.err = parseHeaderTemplate(opts.HeaderTemplate)
+	}
+	return r
+}
+
+// streamError returns the ErrStreamUnsupported error for the first option
+// of opts that MergeReader cannot stream, or nil.
+func streamError(opts MergeOptions) error {
+	switch {
+	case opts.TOC:
+		return fmt.Errorf("%w: table of contents", ErrStreamUnsupported)
+	case opts.Dedup:
+		return fmt.Errorf("%w: dedup", ErrStreamUnsupported)
+	case opts.SortByPriority:
+		return fmt.Errorf("%w: priority order", ErrStreamUnsupported)
+	case opts.MaxChars > 0:
+		return fmt.Errorf("%w: character limit", ErrStreamUnsupported)
+	case len(opts.Sections) > 0:
+		return fmt.Errorf("%w: sections", ErrStreamUnsupported)
+	case opts.Format != "" && opts.Format != FormatMarkdown:
+		return fmt.Errorf("%w: format %s", ErrStreamUnsupported, opts.Format)
+	}
+	return nil
+}
+
+// Read implements io.Reader.
+func (r *MergeReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.next == len(r.files) {
+			return 0, io.EOF
+		}
+		f := r.files[r.next]
+		r.next++
+		r.err = r.load(f)
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// load reads f and sets pending to its header, content and separator, or
+// to nothing if f is disabled or filtered out by tags.
+func (r *MergeReader) load(f PromptFile) error {
+	file := f.file
+	if file == "" {
+		file = f.Path
+	}
+	// A fresh source, rather than f itself, keeps the content from being
+	// retained by the caller's PromptFile once it has been read.
+	src := source{Path: f.Path, File: file, info: f.Info, fsys: f.fsys}
+	doc, err := loadDocument(src, r.opts, nil, r.lookup)
+	if err != nil {
+		return err
+	}
+	if doc.normalized {
+		r.normalized++
+	}
+	include := selected(doc.FrontMatter, r.opts)
+	r.sources = append(r.sources, SourceRecord{
+		Path:     doc.Path,
+		SHA256:   doc.SHA256,
+		Bytes:    doc.Size,
+		Title:    doc.FrontMatter.Title,
+		Included: include,
+	})
+	if !include {
+		return nil
+	}
+
+	r.merged++
+	r.included = append(r.included, doc.Path)
+	var out []byte
+	if r.header != nil {
+		h, err := renderHeader(r.header, doc, r.merged)
+		if err != nil {
+			return err
+		}
+		if !r.atLineStart {
+			out = append(out, '\n')
+		}
+		out = append(out, h...)
+	}
+	out = append(out, doc.Text...)
+	out = append(out, r.opts.Separator...)
+	if len(out) > 0 {
+		r.atLineStart = out[len(out)-1] == '\n'
+	}
+	r.pending = out
+	return nil
+}
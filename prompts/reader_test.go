@@ -0,0 +1,96 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestMergeReaderSlowConsumer(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"p/1.md": "# One\n",
+		"p/2.md": "---\ntags: [skip]\n---\n# Two\n",
+		"p/3.md": "# Three\n",
+	})
+	opts := DefaultMergeOptions()
+	opts.HeaderTemplate = DefaultHeaderTemplate
+	opts.Tags = []string{"core"}
+	files, err := Walk([]string{"p"}, WalkOptions{Extensions: opts.Extensions, Sort: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := io.Copy(pw, NewMergeReader(files, opts))
+		pw.CloseWithError(err)
+	}()
+
+	// Read all but the last byte of the first file's section, a few bytes
+	// at a time. Until that byte is read the pipe holds up the writer.
+	first := "<!-- source: p/1.md -->\n# One\n\n"
+	var got bytes.Buffer
+	for got.Len() < len(first)-1 {
+		if _, err := io.Copy(&got, io.LimitReader(pr, int64(min(5, len(first)-1-got.Len())))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// MergeReader reads files only once the previous ones are consumed,
+	// so a change made now still shows up in the output.
+	if err := os.WriteFile("p/3.md", []byte("# Three, edited\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 3)
+	for {
+		n, err := pr.Read(buf)
+		got.Write(buf[:n])
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := first + "<!-- source: p/3.md -->\n# Three, edited\n\n"
+	if got.String() != want {
+		t.Errorf("streamed %q, want %q", got.String(), want)
+	}
+}
+
+func TestMergeReaderMatchesMerge(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, reproducibleFixture)
+	opts := DefaultMergeOptions()
+	opts.HeaderTemplate = DefaultHeaderTemplate
+	opts.Vars = map[string]string{"CLIENT": "net/http"}
+	res, err := Merge([]string{"general", "libs"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := Walk([]string{"general", "libs"}, WalkOptions{Extensions: opts.Extensions, Sort: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := io.ReadAll(NewMergeReader(files, opts))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(streamed, res.Content) {
+		t.Errorf("streamed\n%s\nwant\n%s", streamed, res.Content)
+	}
+}
+
+func TestMergeReaderUnsupported(t *testing.T) {
+	opts := DefaultMergeOptions()
+	opts.TOC = true
+	_, err := io.ReadAll(NewMergeReader(nil, opts))
+	if !errors.Is(err, ErrStreamUnsupported) {
+		t.Errorf("err = %v, want ErrStreamUnsupported", err)
+	}
+}
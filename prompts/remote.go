@@ -0,0 +1,82 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultFetchTimeout bounds each remote fetch when
+// MergeOptions.FetchTimeout is zero.
+const DefaultFetchTimeout = 10 * time.Second
+
+// isRemote reports whether a MergePrompts dirs entry names a URL rather
+// than a local directory.
+func isRemote(entry string) bool {
+	return strings.HasPrefix(entry, "https://") || strings.HasPrefix(entry, "http://")
+}
+
+// remoteCachePath returns the local file a URL's body is kept in, named
+// after the hash of the URL.
+func remoteCachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	ext := path.Ext(strings.SplitN(url, "?", 2)[0])
+	if ext == "" {
+		ext = ".md"
+	}
+	return filepath.Join(os.TempDir(), "go-prompts", hex.EncodeToString(sum[:])+ext)
+}
+
+// fetchRemote downloads url into its cache file and returns the file's
+// path. If the download fails but an earlier copy is cached, that copy is
+// used and a warning logged.
+func fetchRemote(url string, opts MergeOptions) (string, error) {
+	file := remoteCachePath(url)
+	err := download(url, file, opts)
+	if err == nil {
+		return file, nil
+	}
+	if _, statErr := os.Stat(file); statErr == nil {
+		if opts.Logger != nil {
+			opts.Logger.Warn("using cached copy", "url", url, "err", err)
+		}
+		return file, nil
+	}
+	return "", err
+}
+
+func download(url, file string, opts MergeOptions) error {
+	timeout := opts.FetchTimeout
+	if timeout <= 0 {
+		timeout = DefaultFetchTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("prompts: fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("prompts: fetch %s: %s", url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("prompts: fetch %s: %w", url, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o777); err != nil {
+		return fmt.Errorf("prompts: cache %s: %w", url, err)
+	}
+	if err := os.WriteFile(file, body, 0o666); err != nil {
+		return fmt.Errorf("prompts: cache %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package prompts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// remoteServer serves prompt.md and a slow slow.md, and fails every other
+// path.
+func remoteServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	// Downloads are cached in the system temporary directory.
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv("TMP", tmp)
+	t.Setenv("TEMP", tmp)
+
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/prompt.md":
+			w.Write([]byte("# Remote\n"))
+		case "/slow.md":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(func() {
+		close(release)
+		srv.Close()
+	})
+	return srv
+}
+
+func TestMergeRemote(t *testing.T) {
+	srv := remoteServer(t)
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/local.md": "# Local\n"})
+
+	opts := DefaultMergeOptions()
+	opts.FetchTimeout = 100 * time.Millisecond
+	res, err := Merge([]string{"p", srv.URL + "/prompt.md", srv.URL + "/missing.md", srv.URL + "/slow.md"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sorting puts the URL, "http://...", first.
+	if want := []string{srv.URL + "/prompt.md", "p/local.md"}; !reflect.DeepEqual(res.Files, want) {
+		t.Errorf("files = %q, want %q", res.Files, want)
+	}
+	if got, want := string(res.Content), "# Remote\n\n# Local\n\n"; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+}
+
+func TestMergeRemoteStrict(t *testing.T) {
+	srv := remoteServer(t)
+	inTempDir(t)
+	opts := DefaultMergeOptions()
+	opts.FetchTimeout = 100 * time.Millisecond
+	opts.StrictRemote = true
+	for _, path := range []string{"/missing.md", "/slow.md"} {
+		start := time.Now()
+		if _, err := Merge([]string{srv.URL + path}, opts); err == nil {
+			t.Errorf("%s: strict merge succeeded", path)
+		}
+		if d := time.Since(start); d > 5*time.Second {
+			t.Errorf("%s: merge took %v despite the timeout", path, d)
+		}
+	}
+	if _, err := Merge([]string{srv.URL + "/prompt.md"}, opts); err != nil {
+		t.Errorf("strict merge of a reachable URL: %v", err)
+	}
+}
+
+func TestMergeRemoteUsesCachedCopy(t *testing.T) {
+	srv := remoteServer(t)
+	inTempDir(t)
+	url := srv.URL + "/gone.md"
+	if err := os.MkdirAll(filepath.Dir(remoteCachePath(url)), 0o777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(remoteCachePath(url), []byte("# Cached\n"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	opts := DefaultMergeOptions()
+	opts.StrictRemote = true
+	res, err := Merge([]string{url}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(res.Content), "# Cached\n\n"; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,106 @@
+package prompts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+// reproducibleFixture has several files per directory, nested directories,
+// CRLF line endings and front matter, which are where the order or bytes of
+// the output could depend on the system.
+var reproducibleFixture = map[string]string{
+	"general/b.md":              "# B\r\n\r\nSecond.\r\n",
+	"general/a.md":              "---\ntitle: First\ntags: [core]\n---\n# A\n\nFirst.  \n",
+	"general/c.md":              "# C\n\nThird.\n\n\n",
+	"general/sub/z.md":          "# Z\n\nNested.\n",
+	"general/sub/deeper/y.md":   "# Y\n\nDeeper.\n",
+	"libs/10_http.md":           "# HTTP\n\nUse ${CLIENT}.\n",
+	"libs/02_log.md":            "# Log\n\nLog everything.\n",
+	"libs/schema.json":          "{\"type\": \"object\"}\n",
+	"libs/disabled.md":          "---\ndisabled: true\n---\nNever merged.\n",
+	"libs/sub dir/spaced.md":    "# Spaced\n\nA path with a space.\n",
+	"libs/sub dir/unicode-é.md": "# Unicode\n\nÉ.\n",
+}
+
+// reproducibleRuns is how many times each configuration is merged.
+const reproducibleRuns = 100
+
+func TestMergeReproducible(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  func() MergeOptions
+		cache bool
+		// sha256 is the hash of the output, the same on every system.
+		sha256 string
+	}{
+		{
+			name: "markdown",
+			opts: func() MergeOptions {
+				opts := DefaultMergeOptions()
+				opts.HeaderTemplate = DefaultHeaderTemplate
+				opts.TOC = true
+				opts.Vars = map[string]string{"CLIENT": "net/http"}
+				return opts
+			},
+			sha256: "8ff9ed74f12fbdb8d49fbe5660b8ea12fe636cbae5d00e19c4700e9d2d7dc633",
+		},
+		{
+			name: "markdown cached",
+			opts: func() MergeOptions {
+				opts := DefaultMergeOptions()
+				opts.HeaderTemplate = DefaultHeaderTemplate
+				opts.TOC = true
+				opts.Vars = map[string]string{"CLIENT": "net/http"}
+				return opts
+			},
+			cache:  true,
+			sha256: "8ff9ed74f12fbdb8d49fbe5660b8ea12fe636cbae5d00e19c4700e9d2d7dc633",
+		},
+		{
+			name: "json",
+			opts: func() MergeOptions {
+				opts := DefaultMergeOptions()
+				opts.Format = FormatJSON
+				opts.Tags = []string{"core"}
+				return opts
+			},
+			sha256: "6af576e4ed3a3231f55ef5979c6427f335617b8b458f6ec9eba37dcb9db70656",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t)
+			writeFiles(t, reproducibleFixture)
+			opts := tt.opts()
+			if tt.cache {
+				opts.CacheFile = DefaultCacheFile
+			}
+
+			var first []byte
+			for i := range reproducibleRuns {
+				res, err := Merge([]string{"general", "libs"}, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if tt.cache {
+					if err := res.WriteFile("prompt.md"); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if i == 0 {
+					first = res.Content
+					continue
+				}
+				if !bytes.Equal(res.Content, first) {
+					t.Fatalf("run %d differs from the first:\n%s", i+1, UnifiedDiff("first", "later", first, res.Content))
+				}
+			}
+			sum := sha256.Sum256(first)
+			if got := hex.EncodeToString(sum[:]); got != tt.sha256 {
+				t.Errorf("output SHA-256 = %s, want %s; output:\n%s", got, tt.sha256, first)
+			}
+		})
+	}
+}
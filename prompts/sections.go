@@ -0,0 +1,135 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrSectionMissing is returned by Merge with RequireSection when a file
+// has none of the headings in MergeOptions.Sections.
+var ErrSectionMissing = errors.New("prompts: section not found")
+
+// sectionQuery is a heading requested through MergeOptions.Sections.
+type sectionQuery struct {
+	heading string // the heading line written above the collected sections
+	level   int    // 0 matches the title at any level
+	title   string
+}
+
+func parseSectionQuery(s string) sectionQuery {
+	if level, title, ok := parseHeading(s); ok {
+		return sectionQuery{heading: strings.TrimSpace(s), level: level, title: title}
+	}
+	title := strings.TrimSpace(s)
+	return sectionQuery{heading: "## " + title, title: title}
+}
+
+func (q sectionQuery) match(n *Node) bool {
+	return n.Title == q.title && (q.level == 0 || n.Level == q.level)
+}
+
+// concatSections builds the document for MergeOptions.Sections: for each
+// requested heading, in order, the heading once, followed by the matching
+// section of every document that has one, each with its source header. It
+// returns the documents that contributed at least one section.
+func concatSections(docs []*document, opts MergeOptions) ([]byte, []*document, error) {
+	if opts.HeaderTemplate == "" {
+		opts.HeaderTemplate = DefaultHeaderTemplate
+	}
+	opts.TOC = false
+
+	queries := make([]sectionQuery, len(opts.Sections))
+	for i, s := range opts.Sections {
+		queries[i] = parseSectionQuery(s)
+	}
+
+	// found[i][j] is the text of query j's sections in docs[i].
+	found := make([][][]byte, len(docs))
+	for i, doc := range docs {
+		tree, err := ParsePromptTree(bytes.NewReader(doc.Text))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", doc.Path, err)
+		}
+		found[i] = make([][]byte, len(queries))
+		has := false
+		for j, q := range queries {
+			var text bytes.Buffer
+			for _, n := range findSections(tree.Root, q) {
+				if err := renderSection(&text, n); err != nil {
+					return nil, nil, err
+				}
+			}
+			if text.Len() > 0 {
+				found[i][j] = text.Bytes()
+				has = true
+			}
+		}
+		if !has && opts.RequireSection {
+			return nil, nil, fmt.Errorf("%w: %s: %s", ErrSectionMissing, doc.Path, strings.Join(opts.Sections, ", "))
+		}
+	}
+
+	var buf bytes.Buffer
+	var used []*document
+	seen := make(map[*document]bool)
+	for j, q := range queries {
+		var parts []*document
+		for i, doc := range docs {
+			if found[i][j] == nil {
+				continue
+			}
+			part := *doc
+			part.Text = found[i][j]
+			parts = append(parts, &part)
+			if !seen[doc] {
+				seen[doc] = true
+				used = append(used, doc)
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		buf.WriteString(q.heading + "\n\n")
+		if _, err := writeDocuments(&buf, parts, opts); err != nil {
+			return nil, nil, err
+		}
+	}
+	return buf.Bytes(), used, nil
+}
+
+// findSections returns the nodes below n matching q, in document order.
+// The descendants of a match are part of it and are not searched.
+func findSections(n *Node, q sectionQuery) []*Node {
+	var out []*Node
+	for _, c := range n.Children {
+		if q.match(c) {
+			out = append(out, c)
+			continue
+		}
+		out = append(out, findSections(c, q)...)
+	}
+	return out
+}
+
+// renderSection writes the content of n without its heading, with blank
+// lines around it trimmed and a final newline.
+func renderSection(b *bytes.Buffer, n *Node) error {
+	var text bytes.Buffer
+	body := *n
+	body.Level = 0
+	if err := body.Render(&text); err != nil {
+		return err
+	}
+	trimmed := bytes.Trim(text.Bytes(), "\n")
+	if len(trimmed) == 0 {
+		return nil
+	}
+	if b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+	b.Write(trimmed)
+	b.WriteByte('\n')
+	return nil
+}
@@ -0,0 +1,40 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DefaultMaxFileSize is the MaxFileSize set by DefaultMergeOptions.
+const DefaultMaxFileSize = 1 << 20
+
+// ErrFileTooLarge is returned by ValidateFileSize for a file over the
+// limit.
+var ErrFileTooLarge = errors.New("prompts: file too large")
+
+// ValidateFileSize reports an error wrapping ErrFileTooLarge if info
+// describes a file larger than maxBytes. A maxBytes of zero or less
+// disables the check.
+func ValidateFileSize(info os.FileInfo, maxBytes int64) error {
+	if maxBytes > 0 && info.Size() > maxBytes {
+		return fmt.Errorf("%w: %s is %d bytes, over the limit of %d", ErrFileTooLarge, info.Name(), info.Size(), maxBytes)
+	}
+	return nil
+}
+
+// checkSize applies opts.MaxFileSize to the file at path. It reports
+// whether the file should be merged, or an error with StrictSize.
+func checkSize(path string, info os.FileInfo, opts MergeOptions) (bool, error) {
+	err := ValidateFileSize(info, opts.MaxFileSize)
+	if err == nil {
+		return true, nil
+	}
+	if opts.StrictSize {
+		return false, fmt.Errorf("%w: %s is %d bytes, over the limit of %d", ErrFileTooLarge, path, info.Size(), opts.MaxFileSize)
+	}
+	if opts.Logger != nil {
+		opts.Logger.Warn("skipping large file", "file", path, "size", info.Size(), "limit", opts.MaxFileSize)
+	}
+	return false, nil
+}
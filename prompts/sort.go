@@ -0,0 +1,78 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SortMode selects the order in which discovered files are merged.
+type SortMode string
+
+const (
+	// SortLexicographic orders files by path. It is the default.
+	SortLexicographic SortMode = "lexicographic"
+
+	// SortDepthFirst keeps the files of each directory passed to Merge
+	// together, in the order the directories were given, and within each
+	// puts shallower paths before deeper ones.
+	SortDepthFirst SortMode = "depth-first"
+
+	// SortBreadthFirst orders files by depth below their directory, so all
+	// top-level files of every directory come before any nested file.
+	SortBreadthFirst SortMode = "breadth-first"
+
+	// SortMTime orders files by modification time, oldest first.
+	SortMTime SortMode = "mtime"
+)
+
+// ErrUnknownSortMode is returned for a sort mode name that is not one of
+// the SortMode constants.
+var ErrUnknownSortMode = errors.New("prompts: unknown sort mode")
+
+// ParseSortMode converts a name such as "mtime" to a SortMode.
+func ParseSortMode(s string) (SortMode, error) {
+	switch m := SortMode(s); m {
+	case SortLexicographic, SortDepthFirst, SortBreadthFirst, SortMTime:
+		return m, nil
+	case "":
+		return SortLexicographic, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownSortMode, s)
+}
+
+// sortSources orders files by mode. Every mode falls back to lexicographic
+// order for files it considers equal.
+func sortSources(files []source, mode SortMode) {
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	var less func(a, b source) bool
+	switch mode {
+	case SortDepthFirst:
+		less = func(a, b source) bool {
+			if a.rootIndex != b.rootIndex {
+				return a.rootIndex < b.rootIndex
+			}
+			return depth(a) < depth(b)
+		}
+	case SortBreadthFirst:
+		less = func(a, b source) bool { return depth(a) < depth(b) }
+	case SortMTime:
+		less = func(a, b source) bool { return a.info.ModTime().Before(b.info.ModTime()) }
+	default:
+		return
+	}
+	sort.SliceStable(files, func(i, j int) bool { return less(files[i], files[j]) })
+}
+
+// depth returns how many directories separate a file from the root it was
+// found under; files directly in the root have depth 0.
+func depth(s source) int {
+	rel, err := filepath.Rel(s.root, s.Path)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(rel), "/")
+}
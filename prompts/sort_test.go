@@ -0,0 +1,71 @@
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortModes(t *testing.T) {
+	inTempDir(t)
+	// Modification times run opposite to the lexicographic order, apart
+	// from b/z.md, the newest.
+	mtimes := map[string]int{
+		"a/k/l/o.md":   7,
+		"a/k/n.md":     6,
+		"a/m.md":       5,
+		"b/x/w/u/t.md": 4,
+		"b/x/w/v.md":   3,
+		"b/x/y.md":     2,
+		"b/z.md":       8,
+	}
+	files := map[string]string{}
+	for name := range mtimes {
+		files[name] = "# " + name + "\n"
+	}
+	writeFiles(t, files)
+	for name, minutes := range mtimes {
+		mtime := fixtureTime.Add(time.Duration(minutes) * time.Minute)
+		if err := os.Chtimes(filepath.FromSlash(name), mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		mode SortMode
+		want []string
+	}{
+		{SortLexicographic, []string{"a/k/l/o.md", "a/k/n.md", "a/m.md", "b/x/w/u/t.md", "b/x/w/v.md", "b/x/y.md", "b/z.md"}},
+		{SortDepthFirst, []string{"b/z.md", "b/x/y.md", "b/x/w/v.md", "b/x/w/u/t.md", "a/m.md", "a/k/n.md", "a/k/l/o.md"}},
+		{SortBreadthFirst, []string{"a/m.md", "b/z.md", "a/k/n.md", "b/x/y.md", "a/k/l/o.md", "b/x/w/v.md", "b/x/w/u/t.md"}},
+		{SortMTime, []string{"b/x/y.md", "b/x/w/v.md", "b/x/w/u/t.md", "a/m.md", "a/k/n.md", "a/k/l/o.md", "b/z.md"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			opts := DefaultMergeOptions()
+			opts.SortMode = tt.mode
+			res, err := Merge([]string{"b", "a"}, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(res.Files))
+			for i, f := range res.Files {
+				got[i] = filepath.ToSlash(f)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSortMode(t *testing.T) {
+	if m, err := ParseSortMode(""); err != nil || m != SortLexicographic {
+		t.Errorf(`ParseSortMode("") = %q, %v`, m, err)
+	}
+	if _, err := ParseSortMode("random"); err == nil {
+		t.Error(`ParseSortMode("random") succeeded`)
+	}
+}
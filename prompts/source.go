@@ -0,0 +1,163 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Source is where Merge finds and reads local prompt files. The zero
+// MergeOptions.Source reads the file system; GitSource reads a Git
+// revision. Remote files are always downloaded.
+type Source interface {
+	// Walk calls fn for every file at or below dir, in any order. If fn
+	// returns filepath.SkipAll, Walk stops and returns nil; any other
+	// error stops Walk and is returned.
+	Walk(dir string, fn func(path string, info os.FileInfo) error) error
+
+	// Stat describes the file at path.
+	Stat(path string) (os.FileInfo, error)
+
+	// ReadFile returns the content of the file at path.
+	ReadFile(path string) ([]byte, error)
+}
+
+// osSource is the Source for the file system.
+type osSource struct{}
+
+func (osSource) Walk(dir string, fn func(string, os.FileInfo) error) error {
+	var fnErr error
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		fnErr = fn(path, info)
+		return fnErr
+	})
+	if err != nil && err != fnErr {
+		return fmt.Errorf("prompts: walk %s: %w", dir, err)
+	}
+	return err
+}
+
+func (osSource) Stat(path string) (os.FileInfo, error) { return os.Stat(path) }
+
+func (osSource) ReadFile(path string) ([]byte, error) { return os.ReadFile(path) }
+
+// sourceOf returns opts.Source, or the file system if it is nil.
+func sourceOf(opts MergeOptions) Source {
+	if opts.Source == nil {
+		return osSource{}
+	}
+	return opts.Source
+}
+
+// ErrGitUnavailable is returned by GitSource when the git command cannot be
+// found.
+var ErrGitUnavailable = errors.New("prompts: git is not available")
+
+// GitSource reads files from a Git revision, such as a branch, tag or
+// commit, using the git command rather than the working tree. Paths are
+// relative to Dir, the current directory if empty, which must be inside
+// the repository. Files from Git have no modification time.
+type GitSource struct {
+	Ref string
+	Dir string
+}
+
+func (g GitSource) Walk(dir string, fn func(string, os.FileInfo) error) error {
+	out, err := g.git("ls-tree", "-r", "-l", "-z", g.Ref, "--", dir)
+	if err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		return fmt.Errorf("prompts: %s not found in %s", dir, g.Ref)
+	}
+	for _, entry := range bytes.Split(bytes.TrimSuffix(out, []byte{0}), []byte{0}) {
+		// "<mode> <type> <object> <size>\t<path>"
+		meta, name, ok := bytes.Cut(entry, []byte("\t"))
+		fields := strings.Fields(string(meta))
+		if !ok || len(fields) != 4 {
+			return fmt.Errorf("prompts: git ls-tree: unexpected entry %q", entry)
+		}
+		if fields[1] != "blob" {
+			continue
+		}
+		size, _ := strconv.ParseInt(fields[3], 10, 64)
+		p := filepath.FromSlash(string(name))
+		err := fn(p, gitFileInfo{name: path.Base(string(name)), size: size})
+		if errors.Is(err, filepath.SkipAll) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g GitSource) Stat(p string) (os.FileInfo, error) {
+	out, err := g.git("cat-file", "-s", g.object(p))
+	if err != nil {
+		return nil, err
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: git cat-file: %w", err)
+	}
+	return gitFileInfo{name: filepath.Base(p), size: size}, nil
+}
+
+func (g GitSource) ReadFile(p string) ([]byte, error) {
+	return g.git("cat-file", "blob", g.object(p))
+}
+
+// object names the blob at p, relative to Dir, in g.Ref.
+func (g GitSource) object(p string) string {
+	return g.Ref + ":./" + filepath.ToSlash(filepath.Clean(p))
+}
+
+// git runs a git command in g.Dir and returns its standard output.
+func (g GitSource) git(args ...string) ([]byte, error) {
+	bin, err := exec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGitUnavailable, err)
+	}
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = g.Dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		line, _, _ := strings.Cut(stderr.String(), "\n")
+		msg := strings.TrimSpace(line)
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("prompts: git %s: %s", args[0], msg)
+	}
+	return out, nil
+}
+
+// gitFileInfo describes a blob listed by GitSource.
+type gitFileInfo struct {
+	name string
+	size int64
+}
+
+func (i gitFileInfo) Name() string       { return i.name }
+func (i gitFileInfo) Size() int64        { return i.size }
+func (i gitFileInfo) Mode() os.FileMode  { return 0o444 }
+func (i gitFileInfo) ModTime() time.Time { return time.Time{} }
+func (i gitFileInfo) IsDir() bool        { return false }
+func (i gitFileInfo) Sys() any           { return nil }
@@ -0,0 +1,86 @@
+package prompts
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestGitSource(t *testing.T) {
+	inTempDir(t)
+	first := gitRepo(t, map[string]string{
+		"p/a.md": "# A, first\n",
+		"p/c.md": "# C\n",
+	})
+	// A second commit edits a.md, adds b.md and removes c.md, and the
+	// working tree edits a.md again without committing.
+	writeFiles(t, map[string]string{"p/a.md": "# A, second\n", "p/b.md": "# B\n"})
+	if err := os.Remove("p/c.md"); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"add", "-A"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "second"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %q: %v\n%s", args, err, out)
+		}
+	}
+	writeFiles(t, map[string]string{"p/a.md": "# A, uncommitted\n"})
+
+	tests := []struct {
+		name   string
+		source Source
+		want   string
+	}{
+		{"first commit", GitSource{Ref: first}, "# A, first\n\n# C\n\n"},
+		{"HEAD", GitSource{Ref: "HEAD"}, "# A, second\n\n# B\n\n"},
+		{"branch parent", GitSource{Ref: "HEAD~1"}, "# A, first\n\n# C\n\n"},
+		{"working tree", nil, "# A, uncommitted\n\n# B\n\n"},
+	}
+	for _, tt := range tests {
+		opts := DefaultMergeOptions()
+		opts.Source = tt.source
+		res, err := Merge([]string{"p"}, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(res.Content) != tt.want {
+			t.Errorf("%s: merged %q, want %q", tt.name, res.Content, tt.want)
+		}
+	}
+
+	// Dir locates the repository when the working directory is elsewhere.
+	repo, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	opts := DefaultMergeOptions()
+	opts.Source = GitSource{Ref: first, Dir: repo}
+	files, err := Walk([]string{"p"}, opts.WalkOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files[0].Path != filepath.Join("p", "a.md") || files[0].Info.Size() != int64(len("# A, first\n")) {
+		t.Errorf("Walk at the first commit = %+v", files)
+	}
+	if content, err := files[0].Content(); err != nil || string(content) != "# A, first\n" {
+		t.Errorf("Content() = %q, %v", content, err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		ref  string
+		dir  string
+	}{
+		{"unknown ref", "no-such-ref", "p"},
+		{"missing dir", first, "q"},
+	} {
+		opts.Source = GitSource{Ref: tt.ref, Dir: repo}
+		if _, err := Merge([]string{tt.dir}, opts); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
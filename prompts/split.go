@@ -0,0 +1,136 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrNoSections is returned by SplitMerged when the merged document carries
+// neither source headers nor a table of contents to split on.
+var ErrNoSections = errors.New("prompts: no source markers found")
+
+// Section is one source file recovered from a merged document.
+type Section struct {
+	Path    string
+	Content []byte
+}
+
+var (
+	sourceHeader = regexp.MustCompile(`^<!-- source: (.+) -->$`)
+	anchorLine   = regexp.MustCompile(`^<a id="([^"]*)"></a>$`)
+	tocEntry     = regexp.MustCompile(`^- \[(.*)\]\(#([^)]*)\)$`)
+)
+
+const (
+	tocHeading = "## Table of Contents\n\n"
+	tocRule    = "\n---\n\n"
+)
+
+// SplitMerged recovers the source files of a document produced by Merge
+// with DefaultHeaderTemplate or TOC enabled. separator is the
+// MergeOptions.Separator used for the merge; it is removed from the end of
+// each section, and data files are taken out of their code block again.
+// With headers enabled the sections are byte-for-byte the files that were
+// merged, except that a data file gains a final newline if it had none.
+// Without them, paths come from the table of contents, which only names
+// files by path when they have no front matter title.
+func SplitMerged(merged []byte, separator string) ([]Section, error) {
+	spans, err := locateSections(merged)
+	if err != nil {
+		return nil, err
+	}
+	sections := make([]Section, 0, len(spans))
+	for _, sp := range spans {
+		content := bytes.TrimSuffix(merged[sp.Content:sp.End], []byte(separator))
+		content = unwrapCodeBlock(sp.Path, content)
+		sections = append(sections, Section{Path: sp.Path, Content: content})
+	}
+	return sections, nil
+}
+
+// sectionSpan locates a source file's section in a merged document. The
+// marker (anchor and header) runs from Start to Content, and the file's
+// content, followed by the separator, from Content to End.
+type sectionSpan struct {
+	Path                string
+	Start, Content, End int
+}
+
+// locateSections finds the sections of merged by their source headers or
+// TOC anchors.
+func locateSections(merged []byte) ([]sectionSpan, error) {
+	labels := map[string]string{}
+	bodyStart := 0
+	if rest, ok := bytes.CutPrefix(merged, []byte(tocHeading)); ok {
+		if toc, _, ok := bytes.Cut(rest, []byte(tocRule)); ok {
+			for _, line := range bytes.Split(toc, []byte("\n")) {
+				if m := tocEntry.FindSubmatch(line); m != nil {
+					// A label is only a path if it slugifies to
+					// its anchor; otherwise it is a front matter title.
+					if isAnchorOf(string(m[2]), string(m[1])) {
+						labels[string(m[2])] = string(m[1])
+					}
+				}
+			}
+			bodyStart = len(tocHeading) + len(toc) + len(tocRule)
+		}
+	}
+
+	var spans []sectionSpan
+	for off := bodyStart; off < len(merged); {
+		line, _, _ := bytes.Cut(merged[off:], []byte("\n"))
+		next := off + len(line) + 1
+		if next > len(merged) {
+			next = len(merged)
+		}
+
+		if m := anchorLine.FindSubmatch(line); m != nil {
+			end := next
+			if bytes.HasPrefix(merged[end:], []byte("\n")) {
+				end++
+			}
+			spans = append(spans, sectionSpan{Path: labels[string(m[1])], Start: off, Content: end})
+			next = end
+		} else if m := sourceHeader.FindSubmatch(line); m != nil {
+			if n := len(spans); n > 0 && spans[n-1].Content == off {
+				// The header directly follows its file's TOC anchor.
+				spans[n-1].Content = next
+				spans[n-1].Path = string(m[1])
+			} else {
+				spans = append(spans, sectionSpan{Path: string(m[1]), Start: off, Content: next})
+			}
+		}
+		off = next
+	}
+
+	if len(spans) == 0 {
+		return nil, ErrNoSections
+	}
+	for i := range spans {
+		spans[i].End = len(merged)
+		if i+1 < len(spans) {
+			spans[i].End = spans[i+1].Start
+		}
+		if spans[i].Path == "" {
+			return nil, fmt.Errorf("prompts: section %d has no source path", i+1)
+		}
+	}
+	return spans, nil
+}
+
+// isAnchorOf reports whether id is an anchor name anchorIDs could give the
+// file at path: its slug, possibly with a "-N" suffix.
+func isAnchorOf(id, path string) bool {
+	rest, ok := strings.CutPrefix(id, slugify(path))
+	if !ok {
+		return false
+	}
+	if rest == "" {
+		return true
+	}
+	n, ok := strings.CutPrefix(rest, "-")
+	return ok && n != "" && strings.Trim(n, "0123456789") == ""
+}
@@ -0,0 +1,58 @@
+package prompts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSplitMergedRoundTrip(t *testing.T) {
+	inTempDir(t)
+	files := map[string]string{
+		"general/a.md":        "---\ntitle: First\ntags: [core]\n---\n# A\n\nFirst.  \n",
+		"general/b.md":        "# B\r\n\r\nCRLF.\r\n",
+		"general/c.md":        "No final newline.",
+		"general/d.md":        "Trailing blank lines.\n\n\n",
+		"general/sub/e.md":    "<!-- a comment that is not a header -->\n---\nA rule.\n",
+		"libs/schema.json":    "{\"type\": \"object\"}\n",
+		"libs/multi.yaml":     "---\na: 1\n---\nb: 2\n",
+		"libs/sub dir/é.md":   "# Unicode\n",
+		"libs/empty.md":       "",
+		"libs/separators.md":  "\n\n",
+		"libs/backticks.yaml": "doc: |\n  ````\n",
+	}
+	writeFiles(t, files)
+
+	for _, toc := range []bool{false, true} {
+		opts := DefaultMergeOptions()
+		opts.Normalize = false
+		opts.HeaderTemplate = DefaultHeaderTemplate
+		opts.TOC = toc
+		res, err := Merge([]string{"general", "libs"}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sections, err := SplitMerged(res.Content, opts.Separator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sections) != len(files) {
+			t.Fatalf("toc=%v: got %d sections, want %d", toc, len(sections), len(files))
+		}
+		for _, s := range sections {
+			want, ok := files[s.Path]
+			if !ok {
+				t.Errorf("toc=%v: unexpected section %q", toc, s.Path)
+				continue
+			}
+			if string(s.Content) != want {
+				t.Errorf("toc=%v: %s = %q, want %q", toc, s.Path, s.Content, want)
+			}
+		}
+	}
+}
+
+func TestSplitMergedNoMarkers(t *testing.T) {
+	if _, err := SplitMerged([]byte("# Just a document\n"), "\n"); !errors.Is(err, ErrNoSections) {
+		t.Errorf("err = %v, want ErrNoSections", err)
+	}
+}
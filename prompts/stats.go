@@ -0,0 +1,48 @@
+package prompts
+
+import (
+	"bytes"
+	"time"
+)
+
+// FileStats are metrics about a prompt file's raw content, including its
+// front matter.
+type FileStats struct {
+	Bytes   int       `json:"bytes"`
+	Lines   int       `json:"lines"`
+	Words   int       `json:"words"`
+	Tokens  int       `json:"tokens"` // estimated with TokenizerEstimate
+	ModTime time.Time `json:"last_modified"`
+}
+
+// Stats computes the file's metrics on the first call and returns the same
+// result afterwards, like Content. The counts are zero if the content
+// cannot be read; Content returns the error.
+func (f *PromptFile) Stats() FileStats {
+	if f.content == nil {
+		return f.computeStats()
+	}
+	f.content.statsOnce.Do(func() {
+		f.content.stats = f.computeStats()
+	})
+	return f.content.stats
+}
+
+func (f *PromptFile) computeStats() FileStats {
+	var st FileStats
+	if f.Info != nil {
+		st.ModTime = f.Info.ModTime()
+	}
+	content, err := f.Content()
+	if err != nil {
+		return st
+	}
+	st.Bytes = len(content)
+	st.Lines = bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		st.Lines++
+	}
+	st.Words = len(bytes.Fields(content))
+	st.Tokens = countEstimate(string(content))
+	return st
+}
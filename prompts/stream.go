@@ -0,0 +1,190 @@
+package prompts
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultStreamThreshold is the StreamThreshold set by DefaultMergeOptions.
+const DefaultStreamThreshold = 4 << 20
+
+// WriteMerge merges dirs and writes the document to path, replacing any
+// existing file, as Merge followed by Result.WriteFile does. When the files
+// found add up to more than opts.StreamThreshold bytes and opts can be
+// streamed (see NewMergeReader), they are instead copied one at a time into
+// a temporary file renamed over path once complete, and the cache is not
+// used. The Result of a streamed merge has no Content; its methods read the
+// document back from path when they need it.
+func WriteMerge(dirs []string, path string, opts MergeOptions) (*Result, error) {
+	sources, err := collectFiles(dirs, opts)
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	for _, src := range sources {
+		size += src.info.Size()
+	}
+	if opts.StreamThreshold <= 0 || size <= opts.StreamThreshold || streamError(opts) != nil {
+		res, err := mergeSources(sources, opts)
+		if err != nil {
+			return nil, err
+		}
+		return res, res.WriteFile(path)
+	}
+
+	files := make([]PromptFile, len(sources))
+	for i, src := range sources {
+		files[i] = src.promptFile()
+	}
+	r := NewMergeReader(files, opts)
+	sum, n, err := writeStreamed(path, r)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: write %s: %w", path, err)
+	}
+	return &Result{
+		Files:      r.included,
+		Sources:    r.sources,
+		Normalized: r.normalized,
+		output:     path,
+		outputSum:  sum,
+		outputSize: n,
+	}, nil
+}
+
+// writeStreamed copies r to a temporary file next to path and renames it
+// over path once complete, so that a failed write never leaves the output
+// truncated. It returns the SHA-256 and the size of what was written.
+func writeStreamed(path string, r io.Reader) (sum string, n int64, err error) {
+	tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.%d.tmp", filepath.Base(path), os.Getpid()))
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o666)
+	if err != nil {
+		return "", 0, err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	h := sha256.New()
+	if n, err = io.Copy(io.MultiWriter(f, h), r); err != nil {
+		return "", 0, err
+	}
+	if err := f.Sync(); err != nil {
+		return "", 0, err
+	}
+	if err := f.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// copyOutput writes the output of a streamed merge to path, unless that is
+// where it already is.
+func (r *Result) copyOutput(path string) error {
+	if filepath.Clean(path) == filepath.Clean(r.output) {
+		return nil
+	}
+	content, err := r.openContent()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	if _, _, err := writeStreamed(path, content); err != nil {
+		return fmt.Errorf("prompts: write %s: %w", path, err)
+	}
+	return nil
+}
+
+// contentSHA256 returns the SHA-256 of the merged document.
+func (r *Result) contentSHA256() string {
+	if r.output != "" {
+		return r.outputSum
+	}
+	return sha256Hex(r.Content)
+}
+
+// contentSize returns the size of the merged document in bytes.
+func (r *Result) contentSize() int {
+	if r.output != "" {
+		return int(r.outputSize)
+	}
+	return len(r.Content)
+}
+
+// openContent returns a reader of the merged document, which the caller
+// must close.
+func (r *Result) openContent() (io.ReadCloser, error) {
+	if r.output == "" {
+		return io.NopCloser(bytes.NewReader(r.Content)), nil
+	}
+	f, err := os.Open(r.output)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: open %s: %w", r.output, err)
+	}
+	return f, nil
+}
+
+// CountTokens counts the tokens of the merged document as CountTokens does,
+// reading it back from the output of a streamed merge.
+func (r *Result) CountTokens(tokenizer string) (int, error) {
+	if r.output == "" {
+		return CountTokens(string(r.Content), tokenizer)
+	}
+	f, err := r.openContent()
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return countTokensReader(f, tokenizer)
+}
+
+// countTokensReader counts the tokens of everything read from r. It counts
+// the text in chunks that end before a line starting with a non-space
+// character, where no tokenizer piece can continue, so that the total is
+// the same as that of CountTokens on the whole text.
+func countTokensReader(r io.Reader, tokenizer string) (int, error) {
+	br := bufio.NewReader(r)
+	var chunk strings.Builder
+	total := 0
+	flush := func() error {
+		n, err := CountTokens(chunk.String(), tokenizer)
+		total += n
+		chunk.Reset()
+		return err
+	}
+	for {
+		line, err := br.ReadString('\n')
+		if chunk.Len() > 0 && line != "" && !isSpaceByte(line[0]) {
+			if err := flush(); err != nil {
+				return 0, err
+			}
+		}
+		chunk.WriteString(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := flush(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func isSpaceByte(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
@@ -0,0 +1,84 @@
+package prompts
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestWriteMergeStreams(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{
+		"p/1.md": "# One\n\nfirst  file\n",
+		"p/2.md": "---\ndisabled: true\n---\n# Two\n",
+		"p/3.md": "# Three\r\n\n    indented\n",
+	})
+	opts := DefaultMergeOptions()
+	opts.HeaderTemplate = DefaultHeaderTemplate
+	want, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts.StreamThreshold = 1
+	res, err := WriteMerge([]string{"p"}, "out.md", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Content != nil {
+		t.Error("Content is set; the merge was not streamed")
+	}
+	got, err := os.ReadFile("out.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want.Content) {
+		t.Errorf("streamed output = %q, want %q", got, want.Content)
+	}
+	if !reflect.DeepEqual(res.Files, want.Files) || !reflect.DeepEqual(res.Sources, want.Sources) || res.Normalized != want.Normalized {
+		t.Errorf("streamed result = %v %v %d, want %v %v %d", res.Files, res.Sources, res.Normalized, want.Files, want.Sources, want.Normalized)
+	}
+	if res.contentSHA256() != want.contentSHA256() || res.contentSize() != want.contentSize() {
+		t.Error("streamed result does not describe the output")
+	}
+	for _, tokenizer := range []string{TokenizerWords, TokenizerChars, TokenizerEstimate} {
+		n, err := res.CountTokens(tokenizer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wantN, _ := want.CountTokens(tokenizer); n != wantN {
+			t.Errorf("CountTokens(%s) = %d, want %d", tokenizer, n, wantN)
+		}
+	}
+
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != "p" && e.Name() != "out.md" {
+			t.Errorf("left %s behind", e.Name())
+		}
+	}
+}
+
+func TestWriteMergeInMemory(t *testing.T) {
+	inTempDir(t)
+	writeFiles(t, map[string]string{"p/1.md": "# One\n"})
+	for name, opts := range map[string]MergeOptions{
+		"under threshold": DefaultMergeOptions(),
+		"toc":             func() MergeOptions { o := DefaultMergeOptions(); o.StreamThreshold = 1; o.TOC = true; return o }(),
+	} {
+		res, err := WriteMerge([]string{"p"}, "out.md", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile("out.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Content == nil || string(got) != string(res.Content) {
+			t.Errorf("%s: output = %q, Content = %q; want the merge held in memory", name, got, res.Content)
+		}
+	}
+}
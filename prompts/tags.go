@@ -0,0 +1,38 @@
+package prompts
+
+import "strings"
+
+// FilterByTags returns the files whose front matter lists at least one of
+// tags. Files without any tags are kept unless requireTags is set. A
+// leading "#" is ignored on both sides, so "#openai" and "openai" are the
+// same tag. An empty tags list keeps every file.
+func FilterByTags(files []PromptFile, tags []string, requireTags bool) []PromptFile {
+	if len(tags) == 0 {
+		return files
+	}
+	var out []PromptFile
+	for _, f := range files {
+		if matchTags(f.FrontMatter.Tags, tags, requireTags) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// matchTags implements the FilterByTags rule for a single file.
+func matchTags(fileTags, tags []string, requireTags bool) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	if len(fileTags) == 0 {
+		return !requireTags
+	}
+	for _, ft := range fileTags {
+		for _, t := range tags {
+			if strings.TrimPrefix(ft, "#") == strings.TrimPrefix(t, "#") {
+				return true
+			}
+		}
+	}
+	return false
+}
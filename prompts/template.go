@@ -0,0 +1,66 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is the value each file is executed with when
+// MergeOptions.Template is set.
+type TemplateData struct {
+	// Path is the source file path as discovered.
+	Path string
+
+	// FrontMatter is the file's parsed front matter.
+	FrontMatter FrontMatter
+
+	// Vars holds MergeOptions.Vars.
+	Vars map[string]string
+}
+
+// TemplateFuncs returns the functions available to every template:
+//
+//	env NAME        the environment variable NAME, or ""
+//	readFile PATH   the content of the file at PATH
+//	now             the current time
+//	toUpper S       S in upper case
+//	toLower S       S in lower case
+//
+// Templates using now, env or readFile are the only way for the same prompt
+// files and options to merge differently between runs.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"env": os.Getenv,
+		"readFile": func(path string) (string, error) {
+			data, err := os.ReadFile(path)
+			return string(data), err
+		},
+		"now":     time.Now,
+		"toUpper": strings.ToUpper,
+		"toLower": strings.ToLower,
+	}
+}
+
+// RenderTemplate executes content as a text/template with data, with the
+// functions of TemplateFuncs and funcs, which take precedence.
+func RenderTemplate(content []byte, data interface{}, funcs template.FuncMap) ([]byte, error) {
+	return renderTemplate("prompt", content, data, funcs)
+}
+
+// renderTemplate is RenderTemplate with a template name, which errors cite
+// along with the line number.
+func renderTemplate(name string, content []byte, data any, funcs template.FuncMap) ([]byte, error) {
+	tmpl, err := template.New(name).Funcs(TemplateFuncs()).Funcs(funcs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("prompts: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("prompts: %w", err)
+	}
+	return buf.Bytes(), nil
+}
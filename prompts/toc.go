@@ -0,0 +1,68 @@
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// writeTOC writes a bulleted table of contents linking to the anchor that
+// writeDocuments places before each document, followed by a "---" rule.
+// ids are the documents' anchor names, as returned by anchorIDs.
+func writeTOC(b *bytes.Buffer, docs []*document, ids []string) {
+	b.WriteString("## Table of Contents\n\n")
+	for i, doc := range docs {
+		label := doc.FrontMatter.Title
+		if label == "" {
+			label = filepath.ToSlash(doc.Path)
+		}
+		fmt.Fprintf(b, "- [%s](#%s)\n", label, ids[i])
+	}
+	b.WriteString("\n---\n\n")
+}
+
+// anchorIDs returns the anchor name of each document: its slugified path,
+// followed by "-2", "-3" and so on when an earlier document already has
+// that name, as "a/b.md" and "a-b.md" would.
+func anchorIDs(docs []*document) []string {
+	ids := make([]string, len(docs))
+	used := make(map[string]bool, len(docs))
+	for i, doc := range docs {
+		slug := slugify(doc.Path)
+		id := slug
+		for n := 2; used[id]; n++ {
+			id = fmt.Sprintf("%s-%d", slug, n)
+		}
+		used[id] = true
+		ids[i] = id
+	}
+	return ids
+}
+
+// anchor returns the HTML anchor named id that a table of contents entry
+// points to.
+func anchor(id string) []byte {
+	return []byte(fmt.Sprintf("<a id=\"%s\"></a>\n\n", id))
+}
+
+// slugify turns a file path into an anchor name: letters and digits are
+// lower-cased and every other run of characters becomes a single "-", so
+// "general/01_structure.md" becomes "general-01-structure-md".
+func slugify(path string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range path {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package prompts
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeTOCDisambiguatesAnchors(t *testing.T) {
+	inTempDir(t)
+	files := map[string]string{
+		"p/a/b.md": "# Nested\n",
+		"p/a-b.md": "# Dashed\n",
+		"p/a_b.md": "# Underscored\n",
+	}
+	writeFiles(t, files)
+	opts := DefaultMergeOptions()
+	opts.TOC = true
+	res, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(res.Content)
+	for _, want := range []string{
+		"- [p/a-b.md](#p-a-b-md)\n",
+		"- [p/a/b.md](#p-a-b-md-2)\n",
+		"- [p/a_b.md](#p-a-b-md-3)\n",
+		`<a id="p-a-b-md"></a>`,
+		`<a id="p-a-b-md-2"></a>`,
+		`<a id="p-a-b-md-3"></a>`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content does not contain %q:\n%s", want, content)
+		}
+	}
+
+	sections, err := SplitMerged(res.Content, opts.Separator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, s := range sections {
+		got[s.Path] = string(s.Content)
+	}
+	if !reflect.DeepEqual(got, files) {
+		t.Errorf("split sections = %q, want %q", got, files)
+	}
+}
+
+func TestIsAnchorOf(t *testing.T) {
+	tests := []struct {
+		id, path string
+		want     bool
+	}{
+		{"a-b-md", "a/b.md", true},
+		{"a-b-md-2", "a/b.md", true},
+		{"a-b-md-12", "a-b.md", true},
+		{"a-b-md-", "a/b.md", false},
+		{"a-b-md-x", "a/b.md", false},
+		{"a-b", "a/b.md", false},
+	}
+	for _, tt := range tests {
+		if got := isAnchorOf(tt.id, tt.path); got != tt.want {
+			t.Errorf("isAnchorOf(%q, %q) = %v, want %v", tt.id, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer names accepted by CountTokens.
+const (
+	TokenizerWords    = "words"
+	TokenizerChars    = "chars"
+	TokenizerEstimate = "estimate"
+)
+
+// ErrUnknownTokenizer is returned by CountTokens for an unsupported
+// tokenizer name.
+var ErrUnknownTokenizer = errors.New("prompts: unknown tokenizer")
+
+// TokenizerName returns the display name of tokenizer: its name, with the
+// names "cl100k" and "cl100k_base" that earlier versions gave
+// TokenizerEstimate reported as "estimate".
+func TokenizerName(tokenizer string) string {
+	if tokenizer == "cl100k" || tokenizer == "cl100k_base" {
+		return TokenizerEstimate
+	}
+	return tokenizer
+}
+
+// CountTokens counts how many tokens text occupies under tokenizer.
+//
+// TokenizerWords counts whitespace-separated words and TokenizerChars counts
+// runes. TokenizerEstimate approximates what BPE tokenizers such as
+// OpenAI's cl100k_base would count: it splits text the way the cl100k_base
+// pre-tokenizer does and charges each piece by length, without the merge
+// table, so its counts are estimates rather than those of a real tokenizer.
+// "cl100k" and "cl100k_base" are accepted as older names for it.
+func CountTokens(text string, tokenizer string) (int, error) {
+	switch tokenizer {
+	case TokenizerWords:
+		return len(strings.Fields(text)), nil
+	case TokenizerChars:
+		return utf8.RuneCountInString(text), nil
+	case TokenizerEstimate, "cl100k", "cl100k_base":
+		return countEstimate(text), nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownTokenizer, tokenizer)
+}
+
+// countEstimate walks text piece by piece following the cl100k_base split
+// pattern:
+//
+//	's|'t|'re|'ve|'m|'ll|'d | [^\r\n\pL\pN]?\pL+ | \pN{1,3} |
+//	 ?[^\s\pL\pN]+[\r\n]* | \s*[\r\n]+ | \s+(?!\S) | \s+
+//
+// Common words are a single token in cl100k_base, so letter runs are
+// charged one token per six bytes; punctuation runs one per four bytes.
+func countEstimate(text string) int {
+	tokens := 0
+	for len(text) > 0 {
+		n, cost := nextPiece(text)
+		tokens += cost
+		text = text[n:]
+	}
+	return tokens
+}
+
+// nextPiece returns the byte length of the first pre-tokenizer piece of s
+// and its estimated token cost.
+func nextPiece(s string) (int, int) {
+	if n := contraction(s); n > 0 {
+		return n, 1
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+
+	// [^\r\n\pL\pN]?\pL+
+	start := 0
+	if !isNewline(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+		if next, _ := utf8.DecodeRuneInString(s[size:]); unicode.IsLetter(next) {
+			start = size
+		}
+	}
+	if n := spanFunc(s[start:], unicode.IsLetter); n > 0 {
+		return start + n, ceilDiv(start+n, 6)
+	}
+
+	// \pN{1,3}
+	if unicode.IsNumber(r) {
+		n, count := 0, 0
+		for n < len(s) && count < 3 {
+			d, w := utf8.DecodeRuneInString(s[n:])
+			if !unicode.IsNumber(d) {
+				break
+			}
+			n += w
+			count++
+		}
+		return n, 1
+	}
+
+	//  ?[^\s\pL\pN]+[\r\n]*
+	isPunct := func(r rune) bool {
+		return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}
+	start = 0
+	if r == ' ' {
+		start = 1
+	}
+	if n := spanFunc(s[start:], isPunct); n > 0 {
+		n += start
+		n += spanFunc(s[n:], isNewline)
+		return n, ceilDiv(n, 4)
+	}
+
+	// \s*[\r\n]+ | \s+(?!\S) | \s+
+	n := spanFunc(s, unicode.IsSpace)
+	if last := strings.LastIndexAny(s[:n], "\r\n"); last >= 0 {
+		return last + 1, 1
+	}
+	if n < len(s) && n > size {
+		// Leave the final space to prefix the following word.
+		_, w := utf8.DecodeLastRuneInString(s[:n])
+		n -= w
+	}
+	return n, 1
+}
+
+func contraction(s string) int {
+	if len(s) < 2 || s[0] != '\'' {
+		return 0
+	}
+	lower := strings.ToLower(s[:min(3, len(s))])
+	for _, c := range []string{"'re", "'ve", "'ll"} {
+		if strings.HasPrefix(lower, c) {
+			return 3
+		}
+	}
+	switch lower[1] {
+	case 's', 't', 'm', 'd':
+		return 2
+	}
+	return 0
+}
+
+func spanFunc(s string, f func(rune) bool) int {
+	for i, r := range s {
+		if !f(r) {
+			return i
+		}
+	}
+	return len(s)
+}
+
+func isNewline(r rune) bool { return r == '\r' || r == '\n' }
+
+func ceilDiv(a, b int) int { return (a + b - 1) / b }
@@ -0,0 +1,39 @@
+package prompts
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCountTokensTokenizers(t *testing.T) {
+	text := "Hello, world! Don't panic.\n"
+	tests := []struct {
+		tokenizer string
+		want      int
+	}{
+		{TokenizerWords, 4},
+		{TokenizerChars, 27},
+	}
+	for _, tt := range tests {
+		if got, err := CountTokens(text, tt.tokenizer); err != nil || got != tt.want {
+			t.Errorf("CountTokens(%s) = %d, %v; want %d", tt.tokenizer, got, err, tt.want)
+		}
+	}
+
+	estimate, err := CountTokens(text, TokenizerEstimate)
+	if err != nil || estimate == 0 {
+		t.Fatalf("CountTokens(estimate) = %d, %v", estimate, err)
+	}
+	for _, alias := range []string{"cl100k", "cl100k_base"} {
+		if got, err := CountTokens(text, alias); err != nil || got != estimate {
+			t.Errorf("CountTokens(%s) = %d, %v; want the estimate %d", alias, got, err, estimate)
+		}
+		if name := TokenizerName(alias); name != TokenizerEstimate {
+			t.Errorf("TokenizerName(%s) = %q, want %q", alias, name, TokenizerEstimate)
+		}
+	}
+
+	if _, err := CountTokens(text, "o200k"); !errors.Is(err, ErrUnknownTokenizer) {
+		t.Errorf("unknown tokenizer: err = %v, want ErrUnknownTokenizer", err)
+	}
+}
@@ -0,0 +1,148 @@
+package prompts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// PromptTree is a Markdown document organised by its ATX headings: each
+// heading owns the text up to the next heading and, as children, the
+// deeper headings that follow it.
+type PromptTree struct {
+	// Root holds the text before the first heading and the top-level
+	// headings as children. Its Level is 0.
+	Root *Node
+}
+
+// Node is a heading and the content it introduces.
+type Node struct {
+	// Level is the heading level, 1 for "#" through 6 for "######".
+	Level int
+
+	// Title is the heading text without the leading hashes.
+	Title string
+
+	// Body is the Markdown between this heading and the next one,
+	// exactly as in the source.
+	Body string
+
+	Children []*Node
+
+	// line is the heading line as parsed, used by Render to reproduce the
+	// input byte for byte. Nodes built by hand are rendered from Level
+	// and Title instead.
+	line string
+}
+
+// ParsePromptTree reads a Markdown document into a PromptTree. Headings
+// inside fenced code blocks are treated as text. Rendering the result
+// reproduces the input exactly.
+func ParsePromptTree(r io.Reader) (*PromptTree, error) {
+	root := &Node{}
+	stack := []*Node{root}
+	cur := root
+	inFence := false
+
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadString('\n')
+		if line != "" {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+				inFence = !inFence
+			}
+			if level, title, ok := parseHeading(line); ok && !inFence {
+				for stack[len(stack)-1].Level >= level {
+					stack = stack[:len(stack)-1]
+				}
+				n := &Node{Level: level, Title: title, line: line}
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+				stack = append(stack, n)
+				cur = n
+			} else {
+				cur.Body += line
+			}
+		}
+		if err == io.EOF {
+			return &PromptTree{Root: root}, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("prompts: parse tree: %w", err)
+		}
+	}
+}
+
+// parseHeading recognises an ATX heading line such as "## Tone".
+func parseHeading(line string) (int, string, bool) {
+	text := strings.TrimRight(line, "\r\n")
+	level := 0
+	for level < len(text) && text[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := text[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+	title := strings.TrimSpace(rest)
+	title = strings.TrimSpace(strings.TrimRight(title, "#"))
+	return level, title, true
+}
+
+// Render writes the tree back out as Markdown.
+func (t *PromptTree) Render(w io.Writer) error {
+	if t.Root == nil {
+		return nil
+	}
+	return t.Root.Render(w)
+}
+
+// Render writes n, its body and all of its descendants as Markdown.
+func (n *Node) Render(w io.Writer) error {
+	if n.Level > 0 {
+		line := n.line
+		if line == "" {
+			line = strings.Repeat("#", n.Level) + " " + n.Title + "\n"
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, n.Body); err != nil {
+		return err
+	}
+	for _, c := range n.Children {
+		if err := c.Render(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Find returns the node reached by following path, a list of heading
+// titles separated by ">" such as "System > Persona > Tone", from the
+// root. Each title must match a direct child of the previous node. Find
+// returns nil if there is no such node.
+func (t *PromptTree) Find(path string) *Node {
+	n := t.Root
+	for _, title := range strings.Split(path, ">") {
+		title = strings.TrimSpace(title)
+		var next *Node
+		for _, c := range n.Children {
+			if c.Title == title {
+				next = c
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		n = next
+	}
+	return n
+}
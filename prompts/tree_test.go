@@ -0,0 +1,87 @@
+package prompts
+
+import (
+	"strings"
+	"testing"
+)
+
+const treeDocument = "Preamble.\r\n\n" +
+	"# System\n" +
+	"You are helpful.\n\n" +
+	"## Persona ##\n" +
+	"### Tone\n" +
+	"Friendly, but brief.\n" +
+	"### Style\n" +
+	"```markdown\n" +
+	"# Not a heading\n" +
+	"```\n" +
+	"#hashtag is not a heading either\n" +
+	"## Rules\n" +
+	"- Be honest.\n" +
+	"#### Skipped levels\n" +
+	"# User\n" +
+	"No final newline."
+
+func TestPromptTreeRoundTrip(t *testing.T) {
+	for _, doc := range []string{"", "no headings\n", "# Only\n", treeDocument} {
+		tree, err := ParsePromptTree(strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var b strings.Builder
+		if err := tree.Render(&b); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != doc {
+			t.Errorf("rendered %q, want %q", b.String(), doc)
+		}
+	}
+}
+
+func TestPromptTreeFind(t *testing.T) {
+	tree, err := ParsePromptTree(strings.NewReader(treeDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path  string
+		level int
+		body  string
+	}{
+		{"System > Persona > Tone", 3, "Friendly, but brief.\n"},
+		{"System>Persona>Style", 3, "```markdown\n# Not a heading\n```\n#hashtag is not a heading either\n"},
+		{"System > Persona", 2, ""},
+		{"System > Rules > Skipped levels", 4, ""},
+		{"User", 1, "No final newline."},
+	}
+	for _, tt := range tests {
+		n := tree.Find(tt.path)
+		if n == nil {
+			t.Errorf("Find(%q) = nil", tt.path)
+			continue
+		}
+		if n.Level != tt.level || n.Body != tt.body {
+			t.Errorf("Find(%q) = level %d, body %q; want level %d, body %q", tt.path, n.Level, n.Body, tt.level, tt.body)
+		}
+	}
+	for _, path := range []string{"Tone", "System > Tone", "System > Persona > Tone > More", "Not a heading"} {
+		if n := tree.Find(path); n != nil {
+			t.Errorf("Find(%q) = %q, want nil", path, n.Title)
+		}
+	}
+}
+
+func TestPromptTreeRenderBuilt(t *testing.T) {
+	tree := &PromptTree{Root: &Node{Children: []*Node{
+		{Level: 1, Title: "System", Body: "Text.\n", Children: []*Node{
+			{Level: 2, Title: "Tone", Body: "Brief.\n"},
+		}},
+	}}}
+	var b strings.Builder
+	if err := tree.Render(&b); err != nil {
+		t.Fatal(err)
+	}
+	if want := "# System\nText.\n## Tone\nBrief.\n"; b.String() != want {
+		t.Errorf("rendered %q, want %q", b.String(), want)
+	}
+}
@@ -0,0 +1,182 @@
+package prompts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// TruncateStrategy selects how content over MergeOptions.MaxChars is cut.
+type TruncateStrategy string
+
+const (
+	// TruncateEnd drops content from the end, i.e. from the last file
+	// backwards.
+	TruncateEnd TruncateStrategy = "end"
+
+	// TruncateMiddle drops content from the middle. Merge removes whole
+	// files from the middle of the merge order, keeping the first and
+	// last, and cuts the end if that is not enough.
+	TruncateMiddle TruncateStrategy = "middle"
+
+	// TruncateError refuses to cut anything; Merge fails with
+	// ErrTooLong instead.
+	TruncateError TruncateStrategy = "error"
+)
+
+var (
+	// ErrUnknownTruncateStrategy is returned for a strategy name that is
+	// not one of the TruncateStrategy constants.
+	ErrUnknownTruncateStrategy = errors.New("prompts: unknown truncate strategy")
+
+	// ErrTooLong is returned by Merge when the merged document exceeds
+	// MergeOptions.MaxChars and the strategy is TruncateError.
+	ErrTooLong = errors.New("prompts: merged content exceeds the character limit")
+)
+
+// ParseTruncateStrategy converts a strategy name such as "middle" to a
+// TruncateStrategy.
+func ParseTruncateStrategy(s string) (TruncateStrategy, error) {
+	switch t := TruncateStrategy(s); t {
+	case TruncateEnd, TruncateMiddle, TruncateError:
+		return t, nil
+	case "":
+		return TruncateEnd, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownTruncateStrategy, s)
+}
+
+// TruncateContent shortens text to at most maxChars characters and returns
+// the result with the number of characters removed. TruncateEnd keeps the
+// beginning of text and TruncateMiddle keeps its beginning and end. With
+// TruncateError text is returned unchanged and the count is the number of
+// characters by which it exceeds the limit.
+func TruncateContent(text string, maxChars int, strategy TruncateStrategy) (string, int) {
+	n := utf8.RuneCountInString(text)
+	if n <= maxChars {
+		return text, 0
+	}
+	maxChars = max(maxChars, 0)
+	removed := n - maxChars
+
+	switch strategy {
+	case TruncateError:
+		return text, removed
+	case TruncateMiddle:
+		head := runePrefix(text, (maxChars+1)/2)
+		tail := runeSuffix(text, maxChars/2)
+		return head + tail, removed
+	}
+	return runePrefix(text, maxChars), removed
+}
+
+// truncateMarker returns the note inserted where content was removed: an
+// HTML comment in Markdown, and plain text in the formats that strip
+// comments.
+func truncateMarker(removed int, format Format) string {
+	if format == FormatText || format == FormatJSON {
+		return fmt.Sprintf("[truncated: %d chars removed]\n", removed)
+	}
+	return fmt.Sprintf("<!-- truncated: %d chars removed -->\n", removed)
+}
+
+// truncate applies opts.Truncate to a merged document that exceeds
+// opts.MaxChars, inserting a truncateMarker where content was dropped so
+// that the marked-up result still fits. It returns the new document and
+// the documents that remain in it, in part or whole.
+func truncate(merged []byte, docs []*document, spans []docSpan, opts MergeOptions) ([]byte, []*document, error) {
+	total := utf8.RuneCount(merged)
+	// Reserve room for the marker, sized for the largest possible count.
+	limit := opts.MaxChars - utf8.RuneCountInString(truncateMarker(total, opts.Format))
+
+	switch opts.Truncate {
+	case TruncateError:
+		return nil, nil, fmt.Errorf("%w: %d > %d", ErrTooLong, total, opts.MaxChars)
+
+	case TruncateMiddle:
+		if out, kept, ok := dropMiddle(docs, opts, total, limit); ok {
+			return out, kept, nil
+		}
+		// Even with only the first and last files the rest does not fit;
+		// cut the end.
+	}
+
+	text, removed := TruncateContent(string(merged), max(limit, 0), TruncateEnd)
+	if !strings.HasSuffix(text, "\n") {
+		// Make room for the newline that ends the cut line.
+		text, removed = TruncateContent(string(merged), max(limit-1, 0), TruncateEnd)
+	}
+	var kept []*document
+	for i, doc := range docs {
+		if spans[i].Text < len(text) {
+			kept = append(kept, doc)
+		}
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	return []byte(text + truncateMarker(removed, opts.Format)), kept, nil
+}
+
+// dropMiddle removes files around the middle of docs, one at a time, until
+// the re-merged document fits in limit characters. The first and last
+// files are never removed. total is the length of the full merge. ok is
+// false if the document never fits.
+func dropMiddle(docs []*document, opts MergeOptions, total, limit int) ([]byte, []*document, bool) {
+	lo, hi := len(docs)/2, len(docs)/2
+	for {
+		// Widen the gap alternately to the right and to the left.
+		canRight, canLeft := hi < len(docs)-1, lo > 1
+		switch {
+		case canRight && ((hi-lo)%2 == 0 || !canLeft):
+			hi++
+		case canLeft:
+			lo--
+		default:
+			return nil, nil, false
+		}
+
+		kept := append(append([]*document{}, docs[:lo]...), docs[hi:]...)
+		var buf bytes.Buffer
+		spans, err := writeDocuments(&buf, kept, opts)
+		if err != nil {
+			return nil, nil, false
+		}
+		out := buf.Bytes()
+		if utf8.RuneCount(out) > limit {
+			continue
+		}
+
+		at := len(out)
+		if lo < len(spans) {
+			at = spans[lo].Start
+		}
+		marker := truncateMarker(total-utf8.RuneCount(out), opts.Format)
+		result := append(append(append([]byte{}, out[:at]...), marker...), out[at:]...)
+		return result, kept, true
+	}
+}
+
+func runePrefix(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+func runeSuffix(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	i := len(s)
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	return s[i:]
+}
@@ -0,0 +1,96 @@
+package prompts
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// truncateFixture has five files of 40 characters each, 41 with the
+// separator.
+var truncateFixture = map[string]string{
+	"p/1.md": strings.Repeat("1", 39) + "\n",
+	"p/2.md": strings.Repeat("2", 39) + "\n",
+	"p/3.md": strings.Repeat("3", 39) + "\n",
+	"p/4.md": strings.Repeat("4", 39) + "\n",
+	"p/5.md": strings.Repeat("5", 39) + "\n",
+}
+
+func TestMergeTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy TruncateStrategy
+		maxChars int
+		format   Format
+		files    []string
+		marker   string
+	}{
+		{
+			name:     "end",
+			strategy: TruncateEnd,
+			maxChars: 120,
+			files:    []string{"p/1.md", "p/2.md"},
+			marker:   "<!-- truncated: 123 chars removed -->\n",
+		},
+		{
+			name:     "end text",
+			strategy: TruncateEnd,
+			maxChars: 120,
+			format:   FormatText,
+			files:    []string{"p/1.md", "p/2.md", "p/3.md"},
+			marker:   "[truncated: 117 chars removed]\n",
+		},
+		{
+			name:     "middle",
+			strategy: TruncateMiddle,
+			maxChars: 170,
+			files:    []string{"p/1.md", "p/4.md", "p/5.md"},
+			marker:   "<!-- truncated: 82 chars removed -->\n",
+		},
+		{
+			name:     "middle keeps first and last",
+			strategy: TruncateMiddle,
+			maxChars: 120,
+			files:    []string{"p/1.md", "p/5.md"},
+			marker:   "<!-- truncated: 123 chars removed -->\n",
+		},
+		{
+			name:     "middle falls back to end",
+			strategy: TruncateMiddle,
+			maxChars: 70,
+			files:    []string{"p/1.md"},
+			marker:   "<!-- truncated: 174 chars removed -->\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t)
+			writeFiles(t, truncateFixture)
+			opts := DefaultMergeOptions()
+			opts.MaxChars = tt.maxChars
+			opts.Truncate = tt.strategy
+			opts.Format = tt.format
+			res, err := Merge([]string{"p"}, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content := string(res.Content)
+			if n := utf8.RuneCountInString(content); n > tt.maxChars {
+				t.Errorf("content is %d characters long, over %d", n, tt.maxChars)
+			}
+			if !strings.Contains(content, tt.marker) {
+				t.Errorf("content = %q, want it to contain %q", content, tt.marker)
+			}
+			if !reflect.DeepEqual(res.Files, tt.files) {
+				t.Errorf("files = %q, want %q", res.Files, tt.files)
+			}
+			for _, s := range res.Sources {
+				if want := slices.Contains(tt.files, s.Path); s.Included != want {
+					t.Errorf("%s: included = %v, want %v", s.Path, s.Included, want)
+				}
+			}
+		})
+	}
+}
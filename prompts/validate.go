@@ -0,0 +1,351 @@
+package prompts
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity grades a Diagnostic.
+type Severity int
+
+const (
+	SeverityWarning Severity = iota + 1
+	SeverityError
+)
+
+// String returns "warning" or "error".
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// MarshalText lets Severity appear as its name in JSON.
+func (s Severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// Diagnostic is a problem found in a prompt file. Line and Col start at 1.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Col      int      `json:"col"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+
+	// Rule names the rule that reported the problem, such as
+	// "line-length", for files to suppress it with lint_ignore in their
+	// front matter. It is empty for rules without a name.
+	Rule string `json:"rule,omitempty"`
+}
+
+// String formats d as "file:line:col: severity: message", the form editor
+// problem matchers expect, followed by the rule name in brackets.
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%s:%d:%d: %s: %s", d.File, d.Line, d.Col, d.Severity, d.Message)
+	if d.Rule != "" {
+		s += " [" + d.Rule + "]"
+	}
+	return s
+}
+
+// Rule is a check run by ValidateFile.
+type Rule interface {
+	// Check returns the problems it finds in content, read from path.
+	Check(path string, content []byte) []Diagnostic
+}
+
+// RuleFunc adapts a function to the Rule interface.
+type RuleFunc func(path string, content []byte) []Diagnostic
+
+// Check calls f.
+func (f RuleFunc) Check(path string, content []byte) []Diagnostic { return f(path, content) }
+
+// DefaultMaxLineLength is the line length above which LineLengthRule warns
+// when its Max is zero.
+const DefaultMaxLineLength = 1000
+
+// DefaultMaxTokensPerFile is the token count above which MaxTokensRule
+// warns when its Max is zero.
+const DefaultMaxTokensPerFile = 2000
+
+// DefaultMinContentTokens is the token count below which MinContentRule
+// warns when its Min is zero.
+const DefaultMinContentTokens = 10
+
+// DefaultRules returns the rules ValidateFile applies when given none.
+func DefaultRules() []Rule {
+	return []Rule{
+		FenceRule{},
+		LinkReferenceRule{},
+		LineLengthRule{},
+		FrontMatterRule{},
+		MaxTokensRule{},
+		MinContentRule{},
+	}
+}
+
+// ValidateFile reads path and returns the diagnostics rules report for it,
+// ordered by position. A nil rules slice means DefaultRules.
+func ValidateFile(path string, rules []Rule) ([]Diagnostic, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: read %s: %w", path, err)
+	}
+	return ValidateContent(path, content, rules), nil
+}
+
+// ValidateContent is like ValidateFile for content already in memory.
+// Diagnostics of the rules named in the file's lint_ignore front matter
+// are dropped.
+func ValidateContent(path string, content []byte, rules []Rule) []Diagnostic {
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	fm, _, _ := FileFrontMatter(path, content)
+	var diags []Diagnostic
+	for _, r := range rules {
+		for _, d := range r.Check(path, content) {
+			if d.Rule == "" || !slices.Contains(fm.LintIgnore, d.Rule) {
+				diags = append(diags, d)
+			}
+		}
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+		return diags[i].Col < diags[j].Col
+	})
+	return diags
+}
+
+// FenceRule reports code fences that are never closed.
+type FenceRule struct{}
+
+// Check implements Rule.
+func (FenceRule) Check(path string, content []byte) []Diagnostic {
+	var open int
+	var marker string
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case marker == "" && (strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")):
+			marker = trimmed[:3]
+			open = i + 1
+		case marker != "" && strings.HasPrefix(trimmed, marker) && strings.Trim(trimmed, marker[:1]) == "":
+			marker = ""
+		}
+	}
+	if marker == "" {
+		return nil
+	}
+	return []Diagnostic{{
+		File: path, Line: open, Col: 1, Severity: SeverityError,
+		Message: "code fence is never closed", Rule: "fence",
+	}}
+}
+
+var (
+	linkRefUse = regexp.MustCompile(`\[([^\]]+)\]\[([^\]]*)\]`)
+	linkRefDef = regexp.MustCompile(`^ {0,3}\[([^\]]+)\]:\s*\S`)
+	inlineCode = regexp.MustCompile("`[^`]*`")
+)
+
+// LinkReferenceRule reports reference-style links, "[text][label]" or
+// "[label][]", whose label has no "[label]: url" definition.
+type LinkReferenceRule struct{}
+
+// Check implements Rule.
+func (LinkReferenceRule) Check(path string, content []byte) []Diagnostic {
+	type use struct {
+		label     string
+		line, col int
+	}
+	defined := map[string]bool{}
+	var uses []use
+
+	forEachProseLine(content, func(n int, line string) {
+		if m := linkRefDef.FindStringSubmatch(line); m != nil {
+			defined[normalizeLabel(m[1])] = true
+			return
+		}
+		line = inlineCode.ReplaceAllStringFunc(line, func(s string) string { return strings.Repeat(" ", len(s)) })
+		for _, m := range linkRefUse.FindAllStringSubmatchIndex(line, -1) {
+			label := line[m[4]:m[5]]
+			if label == "" {
+				label = line[m[2]:m[3]]
+			}
+			uses = append(uses, use{label: label, line: n, col: utf8.RuneCountInString(line[:m[0]]) + 1})
+		}
+	})
+
+	var diags []Diagnostic
+	for _, u := range uses {
+		if !defined[normalizeLabel(u.label)] {
+			diags = append(diags, Diagnostic{
+				File: path, Line: u.line, Col: u.col, Severity: SeverityWarning,
+				Message: fmt.Sprintf("link reference %q is not defined", u.label),
+				Rule:    "link-reference",
+			})
+		}
+	}
+	return diags
+}
+
+func normalizeLabel(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// forEachProseLine calls fn with the 1-based number and text of each line
+// of content outside fenced code blocks.
+func forEachProseLine(content []byte, fn func(n int, line string)) {
+	inFence := false
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if !inFence {
+			fn(i+1, line)
+		}
+	}
+}
+
+// LineLengthRule warns about lines longer than Max characters, or
+// DefaultMaxLineLength when Max is zero.
+type LineLengthRule struct {
+	Max int
+}
+
+// Check implements Rule.
+func (r LineLengthRule) Check(path string, content []byte) []Diagnostic {
+	limit := r.Max
+	if limit <= 0 {
+		limit = DefaultMaxLineLength
+	}
+	var diags []Diagnostic
+	for i, line := range strings.Split(string(content), "\n") {
+		if n := utf8.RuneCountInString(strings.TrimRight(line, "\r")); n > limit {
+			diags = append(diags, Diagnostic{
+				File: path, Line: i + 1, Col: limit + 1, Severity: SeverityWarning,
+				Message: fmt.Sprintf("line is %d characters long, more than %d", n, limit),
+				Rule:    "line-length",
+			})
+		}
+	}
+	return diags
+}
+
+// knownFrontMatterKeys are the front matter keys FrontMatter understands.
+var knownFrontMatterKeys = map[string]bool{
+	"title":       true,
+	"priority":    true,
+	"tags":        true,
+	"disabled":    true,
+	"lint_ignore": true,
+}
+
+// FrontMatterRule reports front matter that cannot be parsed and keys that
+// FrontMatter does not know, which are most likely typos.
+type FrontMatterRule struct{}
+
+// Check implements Rule.
+func (FrontMatterRule) Check(path string, content []byte) []Diagnostic {
+	if codeLanguage(path) != "" {
+		return nil
+	}
+	if _, _, err := ParseFrontMatter(content); err != nil {
+		return []Diagnostic{{
+			File: path, Line: 1, Col: 1, Severity: SeverityError,
+			Message: err.Error(), Rule: "front-matter",
+		}}
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != frontMatterFence {
+		return nil
+	}
+	var diags []Diagnostic
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if strings.TrimSpace(line) == frontMatterFence {
+			return diags
+		}
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+			continue
+		}
+		key, _, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if k := strings.ToLower(strings.TrimSpace(key)); !knownFrontMatterKeys[k] {
+			diags = append(diags, Diagnostic{
+				File: path, Line: i + 1, Col: 1, Severity: SeverityWarning,
+				Message: fmt.Sprintf("unknown front matter key %q", k),
+				Rule:    "front-matter",
+			})
+		}
+	}
+	// The opening fence is never closed, so this is not front matter.
+	return nil
+}
+
+// MaxTokensRule warns about files longer than Max tokens, or
+// DefaultMaxTokensPerFile when Max is zero, which are better split into
+// several focused files. Tokens are estimated with TokenizerEstimate,
+// excluding front matter.
+type MaxTokensRule struct {
+	Max int
+}
+
+// Check implements Rule.
+func (r MaxTokensRule) Check(path string, content []byte) []Diagnostic {
+	limit := r.Max
+	if limit <= 0 {
+		limit = DefaultMaxTokensPerFile
+	}
+	_, body, _ := FileFrontMatter(path, content)
+	if n := countEstimate(string(body)); n > limit {
+		return []Diagnostic{{
+			File: path, Line: 1, Col: 1, Severity: SeverityWarning,
+			Message: fmt.Sprintf("file is %d tokens long, more than %d; consider splitting it", n, limit),
+			Rule:    "max-tokens-per-file",
+		}}
+	}
+	return nil
+}
+
+// MinContentRule warns about files with fewer than Min tokens, or
+// DefaultMinContentTokens when Min is zero, not counting whitespace and
+// front matter. It catches files left empty by accident.
+type MinContentRule struct {
+	Min int
+}
+
+// Check implements Rule.
+func (r MinContentRule) Check(path string, content []byte) []Diagnostic {
+	limit := r.Min
+	if limit <= 0 {
+		limit = DefaultMinContentTokens
+	}
+	_, body, _ := FileFrontMatter(path, content)
+	// Joining the words folds each run of whitespace into the token that
+	// follows it.
+	if n := countEstimate(strings.Join(strings.Fields(string(body)), " ")); n < limit {
+		return []Diagnostic{{
+			File: path, Line: 1, Col: 1, Severity: SeverityWarning,
+			Message: fmt.Sprintf("file has only %d tokens of content, fewer than %d", n, limit),
+			Rule:    "min-content",
+		}}
+	}
+	return nil
+}
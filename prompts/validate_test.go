@@ -0,0 +1,130 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// positions formats diags as "line:col severity rule" for comparison.
+func positions(diags []Diagnostic) []string {
+	var out []string
+	for _, d := range diags {
+		out = append(out, fmt.Sprintf("%d:%d %s %s", d.Line, d.Col, d.Severity, d.Rule))
+	}
+	return out
+}
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    Rule
+		path    string
+		content string
+		want    []string
+	}{
+		{"closed fence", FenceRule{}, "a.md", "```go\nx\n```\n~~~\ny\n~~~~\n", nil},
+		{"unclosed fence", FenceRule{}, "a.md", "# A\n\n```go\nx\n", []string{"3:1 error fence"}},
+		{"fence closed by the other marker", FenceRule{}, "a.md", "~~~\nx\n```\n", []string{"1:1 error fence"}},
+
+		{"defined reference", LinkReferenceRule{}, "a.md", "See [the docs][Docs] and [docs][].\n\n[docs]: https://example.com\n", nil},
+		{"undefined reference", LinkReferenceRule{}, "a.md", "See [the docs][docs].\n", []string{"1:5 warning link-reference"}},
+		{"collapsed reference", LinkReferenceRule{}, "a.md", "x\n\nA [label][] here.\n", []string{"3:3 warning link-reference"}},
+		{"reference in code", LinkReferenceRule{}, "a.md", "`a[i][j]`\n\n```\nb[i][j]\n```\n", nil},
+
+		{"short lines", LineLengthRule{Max: 5}, "a.md", "12345\r\n12345\n", nil},
+		{"long line", LineLengthRule{Max: 5}, "a.md", "12345\n123456\n", []string{"2:6 warning line-length"}},
+		{"default length", LineLengthRule{}, "a.md", strings.Repeat("x", DefaultMaxLineLength+1), []string{"1:1001 warning line-length"}},
+
+		{"known keys", FrontMatterRule{}, "a.md", "---\ntitle: A\ntags: [go]\nlint_ignore: [fence]\n---\n# A\n", nil},
+		{"no front matter", FrontMatterRule{}, "a.md", "# A\n\ntitel: not front matter\n", nil},
+		{"unknown key", FrontMatterRule{}, "a.md", "---\ntitle: A\ntitel: B\n---\n", []string{"3:1 warning front-matter"}},
+		{"malformed", FrontMatterRule{}, "a.md", "---\ntitle: [unclosed\n---\n", []string{"1:1 error front-matter"}},
+		{"yaml file", FrontMatterRule{}, "a.yaml", "---\ntitel: B\n---\n", nil},
+	}
+	for _, tt := range tests {
+		got := positions(tt.rule.Check(tt.path, []byte(tt.content)))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: diagnostics = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateContent(t *testing.T) {
+	content := "---\ntitel: A\n---\n[a][b] " + strings.Repeat("x", DefaultMaxLineLength) + "\n```\n"
+	diags := ValidateContent("a.md", []byte(content), []Rule{FenceRule{}, LineLengthRule{}, LinkReferenceRule{}, FrontMatterRule{}})
+	want := []string{
+		"2:1 warning front-matter",
+		"4:1 warning link-reference",
+		"4:1001 warning line-length",
+		"5:1 error fence",
+	}
+	if got := positions(diags); !reflect.DeepEqual(got, want) {
+		t.Errorf("diagnostics = %q, want %q", got, want)
+	}
+	if s := diags[3].String(); s != "a.md:5:1: error: code fence is never closed [fence]" {
+		t.Errorf("String() = %q", s)
+	}
+
+	custom := RuleFunc(func(path string, content []byte) []Diagnostic {
+		return []Diagnostic{{File: path, Line: 1, Col: 1, Severity: SeverityError, Message: "custom"}}
+	})
+	if got := positions(ValidateContent("a.md", []byte("# A\n"), []Rule{custom})); !reflect.DeepEqual(got, []string{"1:1 error "}) {
+		t.Errorf("custom rule: diagnostics = %q", got)
+	}
+}
+
+func TestValidateFileMissing(t *testing.T) {
+	inTempDir(t)
+	if _, err := ValidateFile("gone.md", nil); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	words := func(n int) string { return strings.Repeat("word ", n) + "\n" }
+	tests := []struct {
+		name    string
+		rule    Rule
+		content string
+		want    []string
+	}{
+		{"under max tokens", MaxTokensRule{Max: 20}, words(10), nil},
+		{"over max tokens", MaxTokensRule{Max: 10}, words(11), []string{"1:1 warning max-tokens-per-file"}},
+		{"front matter not counted", MaxTokensRule{Max: 12}, "---\ntitle: " + words(20) + "---\n" + words(5), nil},
+		{"default max tokens", MaxTokensRule{}, words(DefaultMaxTokensPerFile + 1), []string{"1:1 warning max-tokens-per-file"}},
+
+		{"enough content", MinContentRule{Min: 3}, "one two three\n", nil},
+		{"too little content", MinContentRule{Min: 3}, "one two\n", []string{"1:1 warning min-content"}},
+		{"whitespace not counted", MinContentRule{Min: 3}, "one\n\n\n     two\n\t\n", []string{"1:1 warning min-content"}},
+		{"only front matter", MinContentRule{}, "---\ntitle: " + words(20) + "---\n", []string{"1:1 warning min-content"}},
+		{"empty file", MinContentRule{}, "", []string{"1:1 warning min-content"}},
+		{"default min content", MinContentRule{}, words(DefaultMinContentTokens), nil},
+	}
+	for _, tt := range tests {
+		got := positions(tt.rule.Check("a.md", []byte(tt.content)))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: diagnostics = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLintIgnore(t *testing.T) {
+	rules := []Rule{MinContentRule{}, FenceRule{}}
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"# A\n```\n", []string{"1:1 warning min-content", "2:1 error fence"}},
+		{"---\nlint_ignore: [min-content]\n---\n# A\n```\n", []string{"5:1 error fence"}},
+		{"---\nlint_ignore: [min-content, fence]\n---\n# A\n```\n", nil},
+	}
+	for _, tt := range tests {
+		if got := positions(ValidateContent("a.md", []byte(tt.content), rules)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: diagnostics = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
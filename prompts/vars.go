@@ -0,0 +1,137 @@
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+var (
+	// ErrUndefinedVariable is returned in strict mode when a placeholder
+	// names a variable that is not defined.
+	ErrUndefinedVariable = errors.New("prompts: undefined variable")
+
+	// ErrVariableCycle is returned when variable values refer to each
+	// other in a loop.
+	ErrVariableCycle = errors.New("prompts: variable cycle")
+)
+
+// ExpandVars replaces "${NAME}" and "$NAME" placeholders in text with the
+// value lookup returns for NAME, and "$$" with a literal "$". Values are
+// expanded in turn, so a variable may be defined in terms of others.
+//
+// An undefined variable is left in place unless strict is set, in which case
+// ExpandVars returns an error wrapping ErrUndefinedVariable.
+func ExpandVars(text string, lookup func(name string) (string, bool), strict bool) (string, error) {
+	return expandVars(text, lookup, strict, nil)
+}
+
+func expandVars(text string, lookup func(string) (string, bool), strict bool, stack []string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(text, '$')
+		if i < 0 {
+			b.WriteString(text)
+			return b.String(), nil
+		}
+		b.WriteString(text[:i])
+		text = text[i:]
+
+		name, n := placeholder(text)
+		switch {
+		case n == 0:
+			b.WriteByte('$')
+			text = text[1:]
+			continue
+		case name == "$":
+			b.WriteByte('$')
+			text = text[n:]
+			continue
+		}
+
+		for _, s := range stack {
+			if s == name {
+				return "", fmt.Errorf("%w: %s", ErrVariableCycle, strings.Join(append(stack, name), " -> "))
+			}
+		}
+
+		value, ok := lookup(name)
+		if !ok {
+			if strict {
+				return "", fmt.Errorf("%w: %s", ErrUndefinedVariable, name)
+			}
+			b.WriteString(text[:n])
+			text = text[n:]
+			continue
+		}
+		value, err := expandVars(value, lookup, strict, append(stack, name))
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(value)
+		text = text[n:]
+	}
+}
+
+// placeholder parses the placeholder at the start of s, which begins with
+// "$". It returns the variable name, "$" for an escaped dollar, and the
+// length of the placeholder, or 0 if s does not start with one.
+func placeholder(s string) (string, int) {
+	if len(s) < 2 {
+		return "", 0
+	}
+	switch c := s[1]; {
+	case c == '$':
+		return "$", 2
+	case c == '{':
+		end := strings.IndexByte(s, '}')
+		if end < 0 || !isVarName(s[2:end]) {
+			return "", 0
+		}
+		return s[2:end], end + 1
+	case isVarStart(c):
+		n := 2
+		for n < len(s) && isVarChar(s[n]) {
+			n++
+		}
+		return s[1:n], n
+	}
+	return "", 0
+}
+
+func isVarName(s string) bool {
+	if s == "" || !isVarStart(s[0]) {
+		return false
+	}
+	for i := 1; i < len(s); i++ {
+		if !isVarChar(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isVarStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isVarChar(c byte) bool { return isVarStart(c) || '0' <= c && c <= '9' }
+
+// varLookup returns the lookup function described by opts: opts.Vars first,
+// then the process environment when opts.ExpandEnv is set. It returns nil
+// when no substitution was requested.
+func varLookup(opts MergeOptions) func(string) (string, bool) {
+	if !opts.ExpandEnv && len(opts.Vars) == 0 {
+		return nil
+	}
+	return func(name string) (string, bool) {
+		if v, ok := opts.Vars[name]; ok {
+			return v, true
+		}
+		if opts.ExpandEnv {
+			return os.LookupEnv(name)
+		}
+		return "", false
+	}
+}
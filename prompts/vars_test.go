@@ -0,0 +1,107 @@
+package prompts
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExpandVars(t *testing.T) {
+	vars := map[string]string{
+		"LANG":    "Go",
+		"VERSION": "1.25",
+		"TOOL":    "${LANG} ${VERSION}",
+		"PROMPT":  "Use $TOOL.",
+		"PRICE":   "$$5",
+		"EMPTY":   "",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"$LANG and ${LANG}", "Go and Go"},
+		{"${LANG}uage", "Gouage"},
+		{"nested: ${PROMPT}", "nested: Use Go 1.25."},
+		{"escaped: $$LANG $${LANG}", "escaped: $LANG ${LANG}"},
+		{"escaped value: $PRICE", "escaped value: $5"},
+		{"empty: [$EMPTY]", "empty: []"},
+		{"lone $ and $1 and ${", "lone $ and $1 and ${"},
+		{"trailing $", "trailing $"},
+	}
+	for _, strict := range []bool{false, true} {
+		for _, tt := range tests {
+			got, err := ExpandVars(tt.text, lookup, strict)
+			if err != nil {
+				t.Errorf("ExpandVars(%q, strict=%v): %v", tt.text, strict, err)
+				continue
+			}
+			if got != tt.want {
+				t.Errorf("ExpandVars(%q, strict=%v) = %q, want %q", tt.text, strict, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestExpandVarsUndefined(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "OUTER" {
+			return "[$INNER]", true
+		}
+		return "", false
+	}
+	for _, text := range []string{"$MISSING here", "${MISSING} here", "$OUTER"} {
+		got, err := ExpandVars(text, lookup, false)
+		if err != nil {
+			t.Errorf("ExpandVars(%q): %v", text, err)
+		}
+		if want := strings.NewReplacer("$OUTER", "[$INNER]").Replace(text); got != want {
+			t.Errorf("ExpandVars(%q) = %q, want %q", text, got, want)
+		}
+
+		if _, err := ExpandVars(text, lookup, true); !errors.Is(err, ErrUndefinedVariable) {
+			t.Errorf("ExpandVars(%q, strict): err = %v, want ErrUndefinedVariable", text, err)
+		}
+	}
+}
+
+func TestExpandVarsCycle(t *testing.T) {
+	vars := map[string]string{"A": "$B", "B": "${A}"}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+	_, err := ExpandVars("$A", lookup, false)
+	if !errors.Is(err, ErrVariableCycle) {
+		t.Fatalf("err = %v, want ErrVariableCycle", err)
+	}
+	if !strings.Contains(err.Error(), "A -> B -> A") {
+		t.Errorf("err = %q, want it to show the cycle", err)
+	}
+}
+
+func TestMergeVarsOverrideEnvironment(t *testing.T) {
+	inTempDir(t)
+	t.Setenv("GO_PROMPTS_TEST_NAME", "env")
+	t.Setenv("GO_PROMPTS_TEST_ONLY_ENV", "from env")
+	writeFiles(t, map[string]string{"p/a.md": "$GO_PROMPTS_TEST_NAME, $GO_PROMPTS_TEST_ONLY_ENV, $UNSET_GO_PROMPTS_VAR\n"})
+	opts := DefaultMergeOptions()
+	opts.ExpandEnv = true
+	opts.Vars = map[string]string{"GO_PROMPTS_TEST_NAME": "var"}
+	res, err := Merge([]string{"p"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(res.Content), "var, from env, $UNSET_GO_PROMPTS_VAR\n\n"; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+
+	opts.StrictVars = true
+	if _, err := Merge([]string{"p"}, opts); !errors.Is(err, ErrUndefinedVariable) {
+		t.Errorf("strict merge: err = %v, want ErrUndefinedVariable", err)
+	}
+}
@@ -0,0 +1,228 @@
+package prompts
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PromptFile is a prompt source file and the metadata from its front
+// matter. Its content is only read when Content is first called.
+type PromptFile struct {
+	// Path names the file: a path as discovered under the merged
+	// directories, or a URL for remote files.
+	Path string
+
+	// Info describes the file as found by Walk. It is nil for files not
+	// returned by Walk or Merge.
+	Info os.FileInfo
+
+	// FrontMatter is the file's parsed front matter. Walk does not read
+	// files and leaves it empty.
+	FrontMatter FrontMatter
+
+	file    string // where the content is read from, if not Path
+	fsys    Source // nil for the file system
+	content *lazyContent
+}
+
+// lazyContent holds a file's content once read, and the statistics
+// computed from it. PromptFile refers to it by pointer so that copies share
+// what has been read.
+type lazyContent struct {
+	once sync.Once
+	data []byte
+	err  error
+
+	statsOnce sync.Once
+	stats     FileStats
+}
+
+// Content returns the file's raw content, reading it on the first call
+// and returning the same result afterwards. Files not obtained from Walk
+// or Merge are read on every call.
+func (f *PromptFile) Content() ([]byte, error) {
+	if f.content == nil {
+		return f.read()
+	}
+	f.content.once.Do(func() {
+		f.content.data, f.content.err = f.read()
+	})
+	return f.content.data, f.content.err
+}
+
+func (f *PromptFile) read() ([]byte, error) {
+	file := f.file
+	if file == "" {
+		file = f.Path
+	}
+	fsys := f.fsys
+	if fsys == nil {
+		fsys = osSource{}
+	}
+	data, err := fsys.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("prompts: read %s: %w", f.Path, err)
+	}
+	return data, nil
+}
+
+// WalkOptions controls how Walk discovers files. The fields have the same
+// meaning as in MergeOptions.
+type WalkOptions struct {
+	Extensions       []string
+	Include, Exclude []string
+	Skip             []string
+	Sort             bool
+	SortMode         SortMode
+	Files            []string
+	Order            []string
+	StrictOrder      bool
+	MaxFileSize      int64
+	StrictSize       bool
+	StrictDirs       bool
+	Source           Source
+	FetchTimeout     time.Duration
+	StrictRemote     bool
+	Logger           *slog.Logger
+}
+
+// WalkOptions returns the options with which Walk finds the files that
+// Merge reads with o.
+func (o MergeOptions) WalkOptions() WalkOptions {
+	return WalkOptions{
+		Extensions:   o.Extensions,
+		Include:      o.Include,
+		Exclude:      o.Exclude,
+		Skip:         o.Skip,
+		Sort:         o.Sort,
+		SortMode:     o.SortMode,
+		Files:        o.Files,
+		Order:        o.Order,
+		StrictOrder:  o.StrictOrder,
+		MaxFileSize:  o.MaxFileSize,
+		StrictSize:   o.StrictSize,
+		StrictDirs:   o.StrictDirs,
+		Source:       o.Source,
+		FetchTimeout: o.FetchTimeout,
+		StrictRemote: o.StrictRemote,
+		Logger:       o.Logger,
+	}
+}
+
+// Walk returns the files under dirs that Merge would consider, in merge
+// order, without reading their content. Files that front matter would
+// exclude are still listed, since that needs the content. URL entries in
+// dirs are downloaded, as for Merge.
+func Walk(dirs []string, opts WalkOptions) ([]PromptFile, error) {
+	sources, err := collectFiles(dirs, MergeOptions{
+		Extensions:   opts.Extensions,
+		Include:      opts.Include,
+		Exclude:      opts.Exclude,
+		Skip:         opts.Skip,
+		Sort:         opts.Sort,
+		SortMode:     opts.SortMode,
+		Files:        opts.Files,
+		Order:        opts.Order,
+		StrictOrder:  opts.StrictOrder,
+		MaxFileSize:  opts.MaxFileSize,
+		StrictSize:   opts.StrictSize,
+		StrictDirs:   opts.StrictDirs,
+		Source:       opts.Source,
+		FetchTimeout: opts.FetchTimeout,
+		StrictRemote: opts.StrictRemote,
+		Logger:       opts.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	files := make([]PromptFile, len(sources))
+	for i, src := range sources {
+		files[i] = src.promptFile()
+	}
+	return files, nil
+}
+
+// Select reads the front matter of files and returns, with FrontMatter set,
+// those that Merge would include with opts: the files that are not disabled
+// and pass the tag filter, ordered by priority when opts.SortByPriority is
+// set.
+func Select(files []PromptFile, opts MergeOptions) ([]PromptFile, error) {
+	err := parallel(len(files), opts.Parallelism, func(i int) error {
+		_, err := files[i].Content()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var out []PromptFile
+	for _, f := range files {
+		content, _ := f.Content()
+		if opts.Normalize {
+			content = NormalizeContent(content)
+		}
+		fm, _, err := FileFrontMatter(f.Path, content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Path, err)
+		}
+		f.FrontMatter = fm
+		if selected(fm, opts) {
+			out = append(out, f)
+		}
+	}
+	if opts.SortByPriority {
+		sort.SliceStable(out, func(i, j int) bool {
+			return out[i].FrontMatter.Priority > out[j].FrontMatter.Priority
+		})
+	}
+	return out, nil
+}
+
+// Preload reads the content of every file, several at a time, so that
+// later calls to Content return immediately. It returns the first error in
+// the order of files.
+func Preload(files []PromptFile) error {
+	return parallel(len(files), runtime.NumCPU(), func(i int) error {
+		_, err := files[i].Content()
+		return err
+	})
+}
+
+// parallel calls fn for every index below n from up to workers goroutines
+// (all CPUs if workers is zero or less) and returns the error for the
+// lowest index, so that the result does not depend on scheduling.
+func parallel(n, workers int, fn func(i int) error) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	workers = min(workers, n)
+
+	errs := make([]error, n)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package prompts
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Default timings used by a Watcher whose fields are left zero.
+const (
+	DefaultPollInterval = 100 * time.Millisecond
+	DefaultDebounce     = 200 * time.Millisecond
+)
+
+// EventOp describes what happened to a watched file.
+type EventOp int
+
+const (
+	Create EventOp = iota + 1
+	Write
+	Remove
+)
+
+// String returns the lower-case name of op.
+func (op EventOp) String() string {
+	switch op {
+	case Create:
+		return "create"
+	case Write:
+		return "write"
+	case Remove:
+		return "remove"
+	}
+	return "unknown"
+}
+
+// Event is a single change observed by a Watcher.
+type Event struct {
+	Path string
+	Op   EventOp
+}
+
+// Watcher polls directories for changes to prompt files. Polling keeps the
+// package free of platform-specific notification APIs at the cost of a small
+// delay, which the debounce window hides anyway.
+type Watcher struct {
+	// Dirs are the directories to watch recursively. Directories that do
+	// not exist yet are picked up once they are created.
+	Dirs []string
+
+	// Extensions restricts the watched files, as in MergeOptions. An empty
+	// list means ".md".
+	Extensions []string
+
+	// PollInterval is how often the directories are scanned.
+	PollInterval time.Duration
+
+	// Debounce is how long the tree must stay unchanged before the
+	// accumulated events are delivered, so that saving many files at once
+	// results in a single callback.
+	Debounce time.Duration
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Run watches until ctx is done, calling fn with each settled batch of
+// events. It returns ctx.Err().
+func (w *Watcher) Run(ctx context.Context, fn func([]Event)) error {
+	interval := w.PollInterval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	debounce := w.Debounce
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	prev := w.snapshot()
+	pending := map[string]EventOp{}
+	var lastChange time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			cur := w.snapshot()
+			if diff(prev, cur, pending) {
+				lastChange = now
+			}
+			prev = cur
+
+			if len(pending) > 0 && now.Sub(lastChange) >= debounce {
+				fn(drain(pending))
+			}
+		}
+	}
+}
+
+func (w *Watcher) snapshot() map[string]fileStamp {
+	exts := w.Extensions
+	if len(exts) == 0 {
+		exts = []string{".md"}
+	}
+
+	files := map[string]fileStamp{}
+	for _, dir := range w.Dirs {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !hasExt(path, exts) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			files[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+			return nil
+		})
+	}
+	return files
+}
+
+// diff records the changes between prev and cur into pending and reports
+// whether there were any. A file created and removed within one batch is
+// dropped; a file created and then written stays a Create.
+func diff(prev, cur map[string]fileStamp, pending map[string]EventOp) bool {
+	changed := false
+	for path, stamp := range cur {
+		old, ok := prev[path]
+		switch {
+		case !ok:
+			if pending[path] == Remove {
+				pending[path] = Write
+			} else {
+				pending[path] = Create
+			}
+			changed = true
+		case old != stamp:
+			if pending[path] == 0 {
+				pending[path] = Write
+			}
+			changed = true
+		}
+	}
+	for path := range prev {
+		if _, ok := cur[path]; !ok {
+			if pending[path] == Create {
+				delete(pending, path)
+			} else {
+				pending[path] = Remove
+			}
+			changed = true
+		}
+	}
+	return changed
+}
+
+func drain(pending map[string]EventOp) []Event {
+	events := make([]Event, 0, len(pending))
+	for path, op := range pending {
+		events = append(events, Event{Path: path, Op: op})
+		delete(pending, path)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Path < events[j].Path })
+	return events
+}
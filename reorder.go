@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"gosuda.org/goprompts/internal/cli"
+	"gosuda.org/goprompts/prompts"
+)
+
+// reorderEntry is a file shown by "go-prompts reorder".
+type reorderEntry struct {
+	path     string
+	size     int64
+	title    string
+	disabled bool
+}
+
+// runReorder implements "go-prompts reorder", which lets the user arrange
+// the discovered files in the terminal and saves the result as an order
+// file.
+func runReorder(args []string) {
+	fs := flag.NewFlagSet("reorder", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: go-prompts reorder [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Shows the files a merge with the same flags would include, in merge\n")
+		fmt.Fprintf(fs.Output(), "order, for rearranging:\n\n")
+		fmt.Fprintf(fs.Output(), "  up, down     move the cursor, or the picked file\n")
+		fmt.Fprintf(fs.Output(), "  enter, space pick up or drop the file under the cursor\n")
+		fmt.Fprintf(fs.Output(), "  d            disable or enable the file\n")
+		fmt.Fprintf(fs.Output(), "  w            write -order-file and quit\n")
+		fmt.Fprintf(fs.Output(), "  q            quit without writing\n\n")
+		fs.PrintDefaults()
+	}
+	mergeFlags := cli.AddMergeFlags(fs, "order-file")
+	orderFileFlag := fs.String("order-file", prompts.DefaultOrderFile, "order file to start from and to write")
+	fs.Parse(args)
+
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		log.Fatal("reorder needs an interactive terminal")
+	}
+
+	dirs, opts := mergeOptions(fs, mergeFlags, false)
+	entries, err := reorderEntries(dirs, opts, *orderFileFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(entries) == 0 {
+		log.Fatal("no files to reorder")
+	}
+
+	restore, err := makeRaw(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	save := reorder(os.Stdin, os.Stdout, entries)
+	restore()
+	if !save {
+		return
+	}
+	if err := writeOrderFile(*orderFileFlag, entries); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("Wrote %s\n", *orderFileFlag)
+}
+
+// reorderEntries returns the files a merge of dirs with opts would include,
+// led by those listed in the order file at path, if it exists. Files the
+// order file disables are shown in their place, marked as disabled.
+func reorderEntries(dirs []string, opts prompts.MergeOptions, path string) ([]reorderEntry, error) {
+	order, err := prompts.ReadOrderFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	var disabled []string
+	for _, path := range order {
+		if p, ok := strings.CutPrefix(path, "!"); ok {
+			disabled = append(disabled, p)
+		}
+	}
+	opts.Order = make([]string, len(order))
+	for i, path := range order {
+		opts.Order[i] = strings.TrimPrefix(path, "!")
+	}
+
+	files, err := prompts.Walk(dirs, opts.WalkOptions())
+	if err != nil {
+		return nil, err
+	}
+	if files, err = prompts.Select(files, opts); err != nil {
+		return nil, err
+	}
+	entries := make([]reorderEntry, len(files))
+	for i, f := range files {
+		entries[i] = reorderEntry{
+			path:     f.Path,
+			size:     f.Info.Size(),
+			title:    f.FrontMatter.Title,
+			disabled: slices.Contains(disabled, f.Path),
+		}
+	}
+	return entries, nil
+}
+
+// reorder runs the interactive list on the terminal in and out until the
+// user writes or quits, rearranging entries in place. It reports whether
+// the order should be saved.
+func reorder(in io.Reader, out io.Writer, entries []reorderEntry) bool {
+	// Switch to the alternate screen and hide the cursor while running.
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(out, "\x1b[?25h\x1b[?1049l")
+
+	cursor, top, picked := 0, 0, false
+	keys := bufio.NewReader(in)
+	for {
+		_, height := terminalSize(os.Stdout)
+		rows := max(height-3, 1)
+		if cursor < top {
+			top = cursor
+		} else if cursor >= top+rows {
+			top = cursor - rows + 1
+		}
+		drawReorder(out, entries, cursor, top, rows, picked)
+
+		key, err := readKey(keys)
+		if err != nil {
+			return false
+		}
+		move := 0
+		switch key {
+		case "up", "k":
+			move = -1
+		case "down", "j":
+			move = 1
+		case "enter", " ":
+			picked = !picked
+		case "d":
+			entries[cursor].disabled = !entries[cursor].disabled
+		case "w":
+			return true
+		case "q", "ctrl-c", "esc":
+			return false
+		}
+		next := cursor + move
+		if next < 0 || next >= len(entries) {
+			continue
+		}
+		if picked {
+			entries[cursor], entries[next] = entries[next], entries[cursor]
+		}
+		cursor = next
+	}
+}
+
+// drawReorder redraws rows entries of the list starting at top.
+func drawReorder(w io.Writer, entries []reorderEntry, cursor, top, rows int, picked bool) {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	b.WriteString("\x1b[1m↑/↓ move  enter pick up/drop  d disable  w write  q quit\x1b[0m\r\n\r\n")
+	width := 0
+	for _, e := range entries {
+		width = max(width, len(e.path))
+	}
+	for i := top; i < min(top+rows, len(entries)); i++ {
+		e := entries[i]
+		marker := "  "
+		if i == cursor {
+			marker = "> "
+			if picked {
+				marker = "» "
+			}
+		}
+		line := fmt.Sprintf("%s%3d  %-*s  %10s B  %s", marker, i+1, width, e.path, groupDigits(int(e.size)), e.title)
+		var attrs []string
+		if i == cursor {
+			attrs = append(attrs, "7") // reverse video
+		}
+		if e.disabled {
+			attrs = append(attrs, "2") // dim
+			line += "  (disabled)"
+		}
+		if attrs != nil {
+			line = "\x1b[" + strings.Join(attrs, ";") + "m" + line + "\x1b[0m"
+		}
+		b.WriteString(line + "\r\n")
+	}
+	io.WriteString(w, b.String())
+}
+
+// readKey reads one key press and names it: "up", "down", "enter", "esc",
+// "ctrl-c", or the character typed.
+func readKey(r *bufio.Reader) (string, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case 3:
+		return "ctrl-c", nil
+	case 0x1b:
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+		seq := make([]byte, 2)
+		if _, err := io.ReadFull(r, seq); err != nil {
+			return "", err
+		}
+		switch string(seq) {
+		case "[A", "OA":
+			return "up", nil
+		case "[B", "OB":
+			return "down", nil
+		}
+		return "", nil
+	}
+	return string(c), nil
+}
+
+// writeOrderFile writes entries to path in the format read by
+// prompts.ReadOrderFile, with disabled files after a "!".
+func writeOrderFile(path string, entries []reorderEntry) error {
+	var b strings.Builder
+	b.WriteString("# Merge order, written by go-prompts reorder. Files after \"!\" are\n")
+	b.WriteString("# not merged.\n")
+	for _, e := range entries {
+		if e.disabled {
+			b.WriteString("!")
+		}
+		b.WriteString(e.path + "\n")
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o644)
+}
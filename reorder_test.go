@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gosuda.org/goprompts/internal/cli"
+)
+
+func TestReorderEntriesMatchMerge(t *testing.T) {
+	t.Chdir(writeTree(t, t.TempDir(), mergeTree))
+	if err := os.WriteFile("order.txt", []byte("!p/c.md\np/a.md\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("reorder", flag.ContinueOnError)
+	m := cli.AddMergeFlags(fs, "order-file")
+	if err := fs.Parse([]string{"-out=p/prompt.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.LoadConfig(false); err != nil {
+		t.Fatal(err)
+	}
+	dirs, opts, err := m.Options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := reorderEntries(dirs, opts, "order.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []reorderEntry{
+		{path: filepath.FromSlash("p/c.md"), size: 4, disabled: true},
+		{path: filepath.FromSlash("p/a.md"), size: 23},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("entries = %+v, want %+v", entries, want)
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gosuda.org/goprompts/internal/cli"
+	"gosuda.org/goprompts/prompts"
+)
+
+// maxUploadSize limits the files accepted by POST /validate.
+const maxUploadSize = 10 << 20
+
+// server answers merge, listing and validation requests over HTTP with the
+// options given on the command line.
+type server struct {
+	dirs  []string
+	opts  prompts.MergeOptions
+	token string
+}
+
+// serve runs the HTTP API on addr until it fails. Requests may override
+// dirs and tags; anything else comes from opts. A non-empty token is
+// required as a bearer token on every request.
+func serve(addr, token string, dirs []string, opts prompts.MergeOptions) error {
+	// Concurrent requests must not share the cache file.
+	opts.CacheFile = ""
+	s := &server{dirs: dirs, opts: opts, token: token}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("Serving on %s", addr)
+	return srv.ListenAndServe()
+}
+
+// handler returns the routes of the API behind the token check.
+func (s *server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /merge", s.handleMerge)
+	mux.HandleFunc("GET /files", s.handleFiles)
+	mux.HandleFunc("POST /validate", s.handleValidate)
+	return s.authorize(mux)
+}
+
+// authorize rejects requests without the bearer token when one is set.
+func (s *server) authorize(next http.Handler) http.Handler {
+	if s.token == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// request returns the directories and options for r. The dirs parameter
+// may only name local directories, so that clients cannot read arbitrary
+// files or make the server fetch URLs.
+func (s *server) request(r *http.Request) ([]string, prompts.MergeOptions, error) {
+	dirs, opts := s.dirs, s.opts
+	q := r.URL.Query()
+	if q.Has("dirs") {
+		dirs = cli.SplitList(q.Get("dirs"))
+		for _, dir := range dirs {
+			if !filepath.IsLocal(dir) {
+				return nil, opts, fmt.Errorf("directory %q is not a local path", dir)
+			}
+		}
+	}
+	if q.Has("tags") {
+		opts.Tags = cli.SplitList(q.Get("tags"))
+	}
+	return dirs, opts, nil
+}
+
+// handleMerge serves GET /merge with the merged document.
+func (s *server) handleMerge(w http.ResponseWriter, r *http.Request) {
+	dirs, opts, err := s.request(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := prompts.Merge(dirs, opts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	contentType := "text/plain; charset=utf-8"
+	if opts.Format == prompts.FormatJSON {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(res.Content)
+}
+
+// fileEntry describes a file in the response to GET /files.
+type fileEntry struct {
+	Path    string    `json:"path"`
+	Bytes   int64     `json:"bytes"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// handleFiles serves GET /files with the discovered source files, found by
+// prompts.Walk without reading them.
+func (s *server) handleFiles(w http.ResponseWriter, r *http.Request) {
+	dirs, opts, err := s.request(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	files, err := prompts.Walk(dirs, opts.WalkOptions())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	entries := make([]fileEntry, len(files))
+	for i, f := range files {
+		entries[i].Path = f.Path
+		if f.Info != nil {
+			entries[i].Bytes = f.Info.Size()
+			entries[i].ModTime = f.Info.ModTime()
+		}
+	}
+	writeJSON(w, entries)
+}
+
+// handleValidate serves POST /validate, checking the file uploaded in the
+// "file" field of a multipart form.
+func (s *server) handleValidate(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if !matchesExt(header.Filename, s.opts.Extensions) {
+		http.Error(w, fmt.Sprintf("%s does not have one of the extensions %s", header.Filename, strings.Join(s.opts.Extensions, ", ")), http.StatusBadRequest)
+		return
+	}
+	content, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	diags := prompts.ValidateContent(header.Filename, content, nil)
+	if diags == nil {
+		diags = []prompts.Diagnostic{}
+	}
+	writeJSON(w, diags)
+}
+
+// writeJSON writes v as an indented JSON response.
+func writeJSON(w http.ResponseWriter, v any) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
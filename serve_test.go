@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gosuda.org/goprompts/prompts"
+)
+
+// testServer serves the API for a tree of two prompts, one tagged go, from
+// the current directory.
+func testServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	t.Chdir(writeTree(t, t.TempDir(), map[string]string{
+		"p/a.md":     "---\ntags: [go]\n---\n# A\n",
+		"p/b.md":     "# B\n",
+		"other/c.md": "# C\n",
+	}))
+	opts := prompts.DefaultMergeOptions()
+	opts.StripFrontMatter = true
+	s := &server{dirs: []string{"p"}, opts: opts, token: token}
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// get requests path from ts with the bearer token, if any, and returns the
+// status and body.
+func get(t *testing.T, ts *httptest.Server, path, token string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest("GET", ts.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body bytes.Buffer
+	body.ReadFrom(resp.Body)
+	return resp.StatusCode, body.String()
+}
+
+func TestServeMerge(t *testing.T) {
+	ts := testServer(t, "")
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/merge", http.StatusOK, "# A\n\n# B\n\n"},
+		{"/merge?tags=python", http.StatusOK, "# B\n\n"},
+		{"/merge?dirs=other", http.StatusOK, "# C\n\n"},
+		{"/merge?dirs=../p", http.StatusBadRequest, "not a local path\n"},
+		{"/merge?dirs=/etc", http.StatusBadRequest, "not a local path\n"},
+		{"/", http.StatusNotFound, "404 page not found\n"},
+		{"/merged", http.StatusNotFound, "404 page not found\n"},
+	}
+	for _, tt := range tests {
+		status, body := get(t, ts, tt.path, "")
+		if status != tt.status || !strings.HasSuffix(body, tt.body) {
+			t.Errorf("GET %s = %d %q, want %d %q", tt.path, status, body, tt.status, tt.body)
+		}
+	}
+
+	resp, err := ts.Client().Post(ts.URL+"/merge", "text/plain", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("POST /merge = %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServeFiles(t *testing.T) {
+	ts := testServer(t, "")
+	status, body := get(t, ts, "/files", "")
+	if status != http.StatusOK {
+		t.Fatalf("GET /files = %d %q", status, body)
+	}
+	var entries []fileEntry
+	if err := json.Unmarshal([]byte(body), &entries); err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, e := range entries {
+		paths = append(paths, e.Path)
+		if e.Bytes == 0 || e.ModTime.IsZero() {
+			t.Errorf("%s: bytes=%d mtime=%v, want them set", e.Path, e.Bytes, e.ModTime)
+		}
+	}
+	// Walk lists files without reading them, so tags do not filter.
+	if want := []string{filepath.Join("p", "a.md"), filepath.Join("p", "b.md")}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %q, want %q", paths, want)
+	}
+
+	if status, _ := get(t, ts, "/files?dirs=../p", ""); status != http.StatusBadRequest {
+		t.Errorf("GET /files?dirs=../p = %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestServeErrors(t *testing.T) {
+	ts := testServer(t, "")
+	s := &server{dirs: []string{"p"}, opts: prompts.DefaultMergeOptions()}
+	s.opts.MaxFileSize = 1
+	s.opts.StrictSize = true
+	failing := httptest.NewServer(s.handler())
+	defer failing.Close()
+	for _, path := range []string{"/merge", "/files"} {
+		if status, body := get(t, failing, path, ""); status != http.StatusInternalServerError {
+			t.Errorf("GET %s over -max-file-size = %d %q, want %d", path, status, body, http.StatusInternalServerError)
+		}
+	}
+
+	tests := []struct {
+		name, field, filename, content string
+		status                         int
+	}{
+		{"valid", "file", "new.md", "# New\n", http.StatusOK},
+		{"no file field", "upload", "new.md", "# New\n", http.StatusBadRequest},
+		{"wrong extension", "file", "new.txt", "# New\n", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		fw, err := mw.CreateFormFile(tt.field, tt.filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(tt.content))
+		mw.Close()
+		resp, err := ts.Client().Post(ts.URL+"/validate", mw.FormDataContentType(), &body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: POST /validate = %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+}
+
+func TestServeToken(t *testing.T) {
+	ts := testServer(t, "secret")
+	for _, tt := range []struct {
+		path, token string
+		status      int
+	}{
+		{"/merge", "", http.StatusUnauthorized},
+		{"/merge", "wrong", http.StatusUnauthorized},
+		{"/files", "", http.StatusUnauthorized},
+		{"/", "", http.StatusUnauthorized},
+		{"/merge", "secret", http.StatusOK},
+		{"/files", "secret", http.StatusOK},
+	} {
+		if status, _ := get(t, ts, tt.path, tt.token); status != tt.status {
+			t.Errorf("GET %s with token %q = %d, want %d", tt.path, tt.token, status, tt.status)
+		}
+	}
+}
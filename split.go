@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"gosuda.org/goprompts/prompts"
+)
+
+// runSplit implements "go-prompts split", which writes the sections of a
+// merged file back to their source paths.
+func runSplit(args []string) {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: go-prompts split [flags] [merged-file]\n\n")
+		fmt.Fprintf(fs.Output(), "Splits a file merged with -headers or -toc back into its sources.\n\n")
+		fs.PrintDefaults()
+	}
+	baseFlag := fs.String("base", ".", "directory the recovered source paths are relative to")
+	sepFlag := fs.String("separator", prompts.DefaultMergeOptions().Separator, "separator the merge wrote after each file")
+	dryRunFlag := fs.Bool("dry-run", false, "print the files that would be written without writing them")
+	fs.Parse(args)
+
+	input := "prompt.md"
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		input = fs.Arg(0)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	merged, err := os.ReadFile(input)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sections, err := prompts.SplitMerged(merged, *sepFlag)
+	if err != nil {
+		log.Fatalf("%s: %v", input, err)
+	}
+
+	for _, sec := range sections {
+		if !filepath.IsLocal(sec.Path) {
+			log.Fatalf("%s: refusing to write %s outside of %s", input, sec.Path, *baseFlag)
+		}
+	}
+
+	for _, sec := range sections {
+		path := filepath.Join(*baseFlag, sec.Path)
+		if *dryRunFlag {
+			fmt.Printf("Would write %s (%s bytes)\n", path, groupDigits(len(sec.Content)))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o777); err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(path, sec.Content, 0o666); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if !*dryRunFlag {
+		fmt.Printf("Split %s into %d files under %s\n", input, len(sections), *baseFlag)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gosuda.org/goprompts/internal/cli"
+	"gosuda.org/goprompts/prompts"
+)
+
+// statsEntry is a row printed by "go-prompts stats": a file, or the totals
+// of a directory or of all files.
+type statsEntry struct {
+	File string `json:"file"`
+	prompts.FileStats
+	Tags     []string `json:"tags"`
+	Included bool     `json:"included"`
+}
+
+// statsTotals sums the metrics of the files in a directory, or of all
+// files, and counts how many of them a merge would include.
+type statsTotals struct {
+	Name string `json:"name"`
+	prompts.FileStats
+	Files         int `json:"files"`
+	IncludedFiles int `json:"included_files"`
+}
+
+// add adds the metrics of e to t. The latest modification time is kept.
+func (t *statsTotals) add(e statsEntry) {
+	t.Bytes += e.Bytes
+	t.Lines += e.Lines
+	t.Words += e.Words
+	t.Tokens += e.Tokens
+	if e.ModTime.After(t.ModTime) {
+		t.ModTime = e.ModTime
+	}
+	t.Files++
+	if e.Included {
+		t.IncludedFiles++
+	}
+}
+
+// runStats implements "go-prompts stats", which prints metrics about every
+// file under -dirs, with subtotals per directory and a grand total.
+func runStats(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: go-prompts stats [flags]\n\n")
+		fmt.Fprintf(fs.Output(), "Prints the size, line, word and token counts of the files under -dirs,\n")
+		fmt.Fprintf(fs.Output(), "and whether a merge with the same flags would include them. Token counts\n")
+		fmt.Fprintf(fs.Output(), "are estimates, as for -tokenizer=estimate.\n\n")
+		fs.PrintDefaults()
+	}
+	mergeFlags := cli.AddMergeFlags(fs, "format")
+	formatFlag := fs.String("format", "table", "output format: table or json")
+	fs.Parse(args)
+
+	if *formatFlag != "table" && *formatFlag != "json" {
+		fmt.Fprintf(os.Stderr, "unknown stats format %q\n", *formatFlag)
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	roots, opts := mergeOptions(fs, mergeFlags, false)
+	// Files the order file leaves out are still counted, as excluded.
+	var ordered []string
+	excluded := map[string]bool{}
+	for _, path := range opts.Order {
+		if p, ok := strings.CutPrefix(path, "!"); ok {
+			excluded[filepath.Clean(p)] = true
+		} else {
+			ordered = append(ordered, path)
+		}
+	}
+	opts.Order = ordered
+	files, err := prompts.Walk(roots, opts.WalkOptions())
+	if err != nil {
+		log.Fatal(err)
+	}
+	selected, err := prompts.Select(files, opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+	included := map[string]bool{}
+	for _, f := range selected {
+		included[f.Path] = !excluded[filepath.Clean(f.Path)]
+	}
+
+	entries := make([]statsEntry, 0, len(files))
+	dirs := map[string]*statsTotals{}
+	var total statsTotals
+	total.Name = "total"
+	for _, f := range files {
+		// Select has read every file.
+		content, _ := f.Content()
+		fm, _, _ := prompts.FileFrontMatter(f.Path, content)
+		e := statsEntry{
+			File:      f.Path,
+			FileStats: f.Stats(),
+			Tags:      fm.Tags,
+			Included:  included[f.Path],
+		}
+		if e.Tags == nil {
+			e.Tags = []string{}
+		}
+		entries = append(entries, e)
+
+		dir := filepath.Dir(f.Path)
+		if dirs[dir] == nil {
+			dirs[dir] = &statsTotals{Name: dir + string(filepath.Separator)}
+		}
+		dirs[dir].add(e)
+		total.add(e)
+	}
+	subtotals := make([]statsTotals, 0, len(dirs))
+	for _, t := range dirs {
+		subtotals = append(subtotals, *t)
+	}
+	sort.Slice(subtotals, func(i, j int) bool { return subtotals[i].Name < subtotals[j].Name })
+
+	if *formatFlag == "json" {
+		data, err := json.MarshalIndent(struct {
+			Files       []statsEntry  `json:"files"`
+			Directories []statsTotals `json:"directories"`
+			Total       statsTotals   `json:"total"`
+		}{entries, subtotals, total}, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "file\tbytes\tlines\twords\ttokens\tlast_modified\ttags\tincluded")
+	row := func(name string, st prompts.FileStats, tags, included string) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", tsvField(name), groupDigits(st.Bytes), groupDigits(st.Lines), groupDigits(st.Words), groupDigits(st.Tokens), formatModTime(st.ModTime), tsvField(tags), included)
+	}
+	for _, e := range entries {
+		included := "no"
+		if e.Included {
+			included = "yes"
+		}
+		row(e.File, e.FileStats, strings.Join(e.Tags, ","), included)
+	}
+	for _, t := range append(subtotals, total) {
+		row(t.Name, t.FileStats, "", fmt.Sprintf("%d/%d", t.IncludedFiles, t.Files))
+	}
+	if err := tw.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// formatModTime formats t for the stats table, or "-" if it is unknown.
+func formatModTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.DateTime)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStatsMatchesMerge(t *testing.T) {
+	dir := writeTree(t, t.TempDir(), mergeTree)
+	stdout, stderr, code := runCLI(t, dir, "stats", "-out=p/prompt.md", "-format=json")
+	if code != 0 {
+		t.Fatalf("exit code %d:\n%s", code, stderr)
+	}
+	var stats struct {
+		Files []statsEntry `json:"files"`
+		Total statsTotals  `json:"total"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &stats); err != nil {
+		t.Fatal(err)
+	}
+	// The output and its log are not counted, nor big.md, which is over
+	// the config's max_file_size.
+	got := map[string]bool{}
+	for _, e := range stats.Files {
+		got[filepath.ToSlash(e.File)] = e.Included
+	}
+	want := map[string]bool{"p/a.md": true, "p/b.md": false, "p/c.md": true, "p/python.md": false}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("included = %v, want %v", got, want)
+	}
+	if stats.Total.Files != 4 || stats.Total.IncludedFiles != 2 {
+		t.Errorf("total: %d files, %d included; want 4 and 2", stats.Total.Files, stats.Total.IncludedFiles)
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// terminalSize returns zeros: the terminal size is not detected on this
+// platform.
+func terminalSize(f *os.File) (width, height int) { return 0, 0 }
+
+// makeRaw fails: raw terminal input is not supported on this platform.
+func makeRaw(f *os.File) (restore func(), err error) {
+	return nil, errors.New("interactive terminal input is not supported on this system")
+}
@@ -0,0 +1,44 @@
+//go:build linux || darwin
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// terminalSize returns the width and height in characters of the terminal
+// f is connected to, or zeros if f is not a terminal.
+func terminalSize(f *os.File) (width, height int) {
+	var ws struct{ Row, Col, X, Y uint16 }
+	if ioctl(f, syscall.TIOCGWINSZ, unsafe.Pointer(&ws)) != nil {
+		return 0, 0
+	}
+	return int(ws.Col), int(ws.Row)
+}
+
+// makeRaw switches the terminal f to reading single key presses without
+// echoing them, and returns a function restoring its previous state.
+func makeRaw(f *os.File) (restore func(), err error) {
+	var old syscall.Termios
+	if err := ioctl(f, ioctlGetTermios, unsafe.Pointer(&old)); err != nil {
+		return nil, err
+	}
+	raw := old
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO | syscall.ISIG
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if err := ioctl(f, ioctlSetTermios, unsafe.Pointer(&raw)); err != nil {
+		return nil, err
+	}
+	return func() { ioctl(f, ioctlSetTermios, unsafe.Pointer(&old)) }, nil
+}
+
+func ioctl(f *os.File, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"gosuda.org/goprompts/internal/cli"
+	"gosuda.org/goprompts/prompts"
+)
+
+// validateRule is a rule of "go-prompts validate", which the rules key of
+// the config file refers to by name.
+type validateRule struct {
+	name  string
+	flag  string // the flag setting the rule's threshold, if it has one
+	usage string
+	def   int
+	rule  func(limit int) prompts.Rule
+}
+
+var validateRules = []validateRule{
+	{name: "fence", rule: func(int) prompts.Rule { return prompts.FenceRule{} }},
+	{name: "link-reference", rule: func(int) prompts.Rule { return prompts.LinkReferenceRule{} }},
+	{
+		name: "line-length", flag: "max-line", def: prompts.DefaultMaxLineLength,
+		usage: "warn about lines longer than this many characters",
+		rule:  func(n int) prompts.Rule { return prompts.LineLengthRule{Max: n} },
+	},
+	{name: "front-matter", rule: func(int) prompts.Rule { return prompts.FrontMatterRule{} }},
+	{
+		name: "max-tokens-per-file", flag: "max-tokens", def: prompts.DefaultMaxTokensPerFile,
+		usage: "warn about files longer than this many tokens",
+		rule:  func(n int) prompts.Rule { return prompts.MaxTokensRule{Max: n} },
+	},
+	{
+		name: "min-content", flag: "min-content", def: prompts.DefaultMinContentTokens,
+		usage: "warn about files with fewer than this many tokens of content",
+		rule:  func(n int) prompts.Rule { return prompts.MinContentRule{Min: n} },
+	},
+}
+
+// exampleRule formats the default setting of r for the config file.
+func exampleRule(r validateRule) string {
+	if r.flag == "" {
+		return "true"
+	}
+	return strconv.Itoa(r.def)
+}
+
+// runValidate implements "go-prompts validate", which checks source files
+// for common problems and prints them as "file:line:col: severity: message".
+func runValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: go-prompts validate [flags] [file-or-dir ...]\n\n")
+		fmt.Fprintf(fs.Output(), "Checks prompt files for unterminated code fences, undefined link\n")
+		fmt.Fprintf(fs.Output(), "references, overlong lines, unknown front matter keys, files with too\n")
+		fmt.Fprintf(fs.Output(), "many tokens and files with almost none. The rules key of the config\n")
+		fmt.Fprintf(fs.Output(), "file disables rules or sets their thresholds; a file's lint_ignore\n")
+		fmt.Fprintf(fs.Output(), "front matter lists rules to skip for it.\n\n")
+		fs.PrintDefaults()
+	}
+	configFlag := fs.String("config", "", "YAML file with a rules key (default: "+cli.DefaultConfigFile+" if it exists)")
+	dirsFlag := fs.String("dirs", "general,libs", "comma-separated list of directories to check when no paths are given")
+	extFlag := fs.String("ext", ".md", "comma-separated list of file extensions to check")
+	limits := map[string]*int{}
+	for _, r := range validateRules {
+		if r.flag != "" {
+			limits[r.name] = fs.Int(r.flag, r.def, r.usage)
+		}
+	}
+	failOnWarningFlag := fs.Bool("fail-on-warning", false, "exit with an error on warnings as well as errors")
+	fs.Parse(args)
+
+	configPath := *configFlag
+	if configPath == "" {
+		configPath = cli.DefaultConfigFile
+	}
+	settings, err := readRules(configPath, *configFlag != "")
+	if err != nil {
+		log.Fatal(err)
+	}
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+
+	// An empty, non-nil list runs no rules; nil would mean DefaultRules.
+	rules := []prompts.Rule{}
+	known := map[string]bool{}
+	for _, r := range validateRules {
+		known[r.name] = true
+		setting := settings[r.name]
+		if setting.Disabled {
+			continue
+		}
+		limit := 0
+		switch {
+		case r.flag == "" && setting.Limit != 0:
+			log.Fatalf("%s: %s: rule %s takes no threshold", configPath, cli.RulesKey, r.name)
+		case r.flag == "":
+		case given[r.flag] || setting.Limit == 0:
+			limit = *limits[r.name]
+		default:
+			limit = setting.Limit
+		}
+		rules = append(rules, r.rule(limit))
+	}
+	for name := range settings {
+		if !known[name] {
+			log.Fatalf("%s: %s: unknown rule %q", configPath, cli.RulesKey, name)
+		}
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = cli.SplitList(*dirsFlag)
+	}
+	files, err := findFiles(paths, cli.SplitList(*extFlag))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	failed := false
+	for _, file := range files {
+		diags, err := prompts.ValidateFile(file, rules)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, d := range diags {
+			fmt.Println(d)
+			if d.Severity == prompts.SeverityError || *failOnWarningFlag {
+				failed = true
+			}
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// findFiles expands paths into the files they name: files are kept as they
+// are and directories are walked for files with one of exts.
+func findFiles(paths, exts []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if path == p || matchesExt(path, exts) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// matchesExt reports whether path ends in one of exts, given with or
+// without the leading dot.
+func matchesExt(path string, exts []string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range exts {
+		if ext == e || ext == "."+e {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// longText is prompt content that no default rule warns about.
+const longText = "# Prompt\n\nThis file has enough words in it to count as real content.\n"
+
+func TestValidateExitCode(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		args  []string
+		code  int
+		out   string
+	}{
+		{"clean", map[string]string{"p/a.md": longText}, nil, 0, ""},
+		{"warning", map[string]string{"p/a.md": longText + "\nSee [x][y].\n"}, nil, 0, "p/a.md:5:5: warning: link reference \"y\" is not defined [link-reference]\n"},
+		{"warning failing", map[string]string{"p/a.md": longText + "\nSee [x][y].\n"}, []string{"-fail-on-warning"}, 1, "p/a.md:5:5: warning"},
+		{"error", map[string]string{"p/a.md": longText + "```\n"}, nil, 1, "p/a.md:4:1: error: code fence is never closed [fence]\n"},
+		{"other extension", map[string]string{"p/a.txt": "```\n"}, nil, 0, ""},
+		{"named file", map[string]string{"p/a.txt": "```\n"}, []string{"p/a.txt"}, 1, "p/a.txt:1:1: error"},
+	}
+	for _, tt := range tests {
+		dir := writeTree(t, t.TempDir(), tt.files)
+		args := append([]string{"validate", "-dirs=p"}, tt.args...)
+		stdout, stderr, code := runCLI(t, dir, args...)
+		if code != tt.code {
+			t.Errorf("%s: exit code %d, want %d:\n%s%s", tt.name, code, tt.code, stdout, stderr)
+		}
+		if want := filepath.FromSlash(tt.out); !strings.HasPrefix(stdout, want) || (tt.out == "") != (stdout == "") {
+			t.Errorf("%s: stdout = %q, want %q", tt.name, stdout, want)
+		}
+	}
+}
+
+func TestValidateRulesConfig(t *testing.T) {
+	short := map[string]string{"p/a.md": "# A\n"}
+	tests := []struct {
+		name   string
+		config string
+		args   []string
+		code   int
+		out    string
+	}{
+		{"defaults", "", nil, 0, "p/a.md:1:1: warning: file has only 2 tokens of content, fewer than 10 [min-content]\n"},
+		{"disabled", "rules:\n  min-content: false\n", nil, 0, ""},
+		{"threshold", "rules:\n  min-content: 2\n", nil, 0, ""},
+		{"flag over config", "rules:\n  min-content: 2\n", []string{"-min-content=4"}, 0, "p/a.md:1:1: warning: file has only 2 tokens of content, fewer than 4 [min-content]\n"},
+		{"max tokens", "rules:\n  min-content: false\n  max-tokens-per-file: 2\n", []string{"-fail-on-warning"}, 1, "p/a.md:1:1: warning: file is 3 tokens long, more than 2; consider splitting it [max-tokens-per-file]\n"},
+		{"unknown rule", "rules:\n  no-such-rule: false\n", nil, 1, ""},
+		{"threshold on a rule without one", "rules:\n  fence: 3\n", nil, 1, ""},
+		{"not a mapping", "rules: [fence]\n", nil, 1, ""},
+	}
+	for _, tt := range tests {
+		files := map[string]string{".go-prompts.yaml": tt.config}
+		for name, content := range short {
+			files[name] = content
+		}
+		dir := writeTree(t, t.TempDir(), files)
+		args := append([]string{"validate", "-dirs=p"}, tt.args...)
+		stdout, stderr, code := runCLI(t, dir, args...)
+		if code != tt.code {
+			t.Errorf("%s: exit code %d, want %d:\n%s%s", tt.name, code, tt.code, stdout, stderr)
+		}
+		if want := filepath.FromSlash(tt.out); stdout != want {
+			t.Errorf("%s: stdout = %q, want %q", tt.name, stdout, want)
+		}
+	}
+}
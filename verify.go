@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"gosuda.org/goprompts/prompts"
+)
+
+// runVerify implements "go-prompts verify", which checks a merged file
+// against the checksum written by -checksum-file.
+func runVerify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: go-prompts verify [flags] [merged-file]\n\n")
+		fmt.Fprintf(fs.Output(), "Exits with an error if the merged file was changed after it was generated.\n\n")
+		fs.PrintDefaults()
+	}
+	checksumFlag := fs.String("checksum-file", "", "path of the checksum file (default: the merged file with .sha256 appended)")
+	fs.Parse(args)
+
+	input := "prompt.md"
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		input = fs.Arg(0)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+	checksumPath := *checksumFlag
+	if checksumPath == "" {
+		checksumPath = prompts.ChecksumPath(input)
+	}
+
+	if err := prompts.VerifyChecksum(input, checksumPath); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s matches %s\n", input, checksumPath)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestVerify(t *testing.T) {
+	dir := writeTree(t, t.TempDir(), map[string]string{"general/a.md": "# A\n"})
+	out := filepath.Join(dir, "prompt.md")
+	merge := func() {
+		t.Helper()
+		if _, stderr, code := runCLI(t, dir, "-dirs=general", "-checksum-file"); code != 0 {
+			t.Fatalf("merge: exit code %d:\n%s", code, stderr)
+		}
+	}
+	verify := func(args ...string) (string, string, int) {
+		t.Helper()
+		return runCLI(t, dir, append([]string{"verify"}, args...)...)
+	}
+
+	merge()
+	if stdout, stderr, code := verify(); code != 0 || stdout != "prompt.md matches prompt.md.sha256\n" {
+		t.Fatalf("fresh output: exit code %d, stdout %q:\n%s", code, stdout, stderr)
+	}
+
+	// The sidecar log is not covered by the checksum.
+	if err := os.WriteFile(filepath.Join(dir, "prompt.log.json"), []byte("{}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, code := verify(); code != 0 {
+		t.Errorf("edited log: exit code %d:\n%s", code, stderr)
+	}
+
+	if err := os.WriteFile(out, []byte("# A, edited by hand\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, code := verify(); code == 0 || !strings.Contains(stderr, "checksum mismatch") {
+		t.Errorf("edited output: exit code %d, stderr:\n%s", code, stderr)
+	}
+
+	merge()
+	if _, stderr, code := verify(); code != 0 {
+		t.Errorf("after re-merging: exit code %d:\n%s", code, stderr)
+	}
+
+	// The file and checksum can be named explicitly.
+	if err := os.Rename(out, filepath.Join(dir, "copy.md")); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, code := verify("-checksum-file=prompt.md.sha256", "copy.md"); code != 0 {
+		t.Errorf("renamed output: exit code %d:\n%s", code, stderr)
+	}
+	if _, stderr, code := verify("copy.md"); code == 0 || !strings.Contains(stderr, "copy.md.sha256") {
+		t.Errorf("missing checksum file: exit code %d, stderr:\n%s", code, stderr)
+	}
+}